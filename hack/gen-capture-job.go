@@ -0,0 +1,79 @@
+//go:build ignore
+
+// Command gen-capture-job emits a well-formed batch/v1 Job manifest for running the agent as a
+// time-boxed, indexed on-demand capture (completionMode: Indexed) instead of a DaemonSet.
+//
+// Usage: go run hack/gen-capture-job.go -completions 3 -image quay.io/netobserv/netobserv-ebpf-agent:main > job.yaml
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/template"
+)
+
+var jobTemplate = template.Must(template.New("job").Parse(`apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{.Name}}
+spec:
+  completionMode: Indexed
+  completions: {{.Completions}}
+  parallelism: {{.Completions}}
+  backoffLimitPerIndex: {{.BackoffLimitPerIndex}}
+  maxFailedIndexes: {{.MaxFailedIndexes}}
+  podReplacementPolicy: Failed
+  ttlSecondsAfterFinished: {{.TTLSecondsAfterFinished}}
+  template:
+    spec:
+      restartPolicy: Never
+      containers:
+      - name: netobserv-ebpf-agent
+        image: {{.Image}}
+        env:
+        - name: CAPTURE_JOB_MODE
+          value: "true"
+        - name: CAPTURE_WINDOW
+          value: "{{.CaptureWindow}}"
+        - name: JOB_COMPLETION_INDEX
+          valueFrom:
+            fieldRef:
+              fieldPath: metadata.annotations['batch.kubernetes.io/job-completion-index']
+        securityContext:
+          privileged: true
+`))
+
+type jobParams struct {
+	Name                    string
+	Completions             int
+	BackoffLimitPerIndex    int
+	MaxFailedIndexes        int
+	TTLSecondsAfterFinished int
+	CaptureWindow           string
+	Image                   string
+}
+
+func main() {
+	name := flag.String("name", "netobserv-capture", "Job name")
+	completions := flag.Int("completions", 1, "number of indexed completions (typically the node count)")
+	backoffLimitPerIndex := flag.Int("backoff-limit-per-index", 1, "backoffLimitPerIndex")
+	maxFailedIndexes := flag.Int("max-failed-indexes", 0, "maxFailedIndexes (quorum tolerance)")
+	ttl := flag.Int("ttl-seconds-after-finished", 300, "ttlSecondsAfterFinished")
+	captureWindow := flag.String("capture-window", "60s", "CAPTURE_WINDOW value")
+	image := flag.String("image", "quay.io/netobserv/netobserv-ebpf-agent:main", "agent image")
+	flag.Parse()
+
+	if err := jobTemplate.Execute(os.Stdout, jobParams{
+		Name:                    *name,
+		Completions:             *completions,
+		BackoffLimitPerIndex:    *backoffLimitPerIndex,
+		MaxFailedIndexes:        *maxFailedIndexes,
+		TTLSecondsAfterFinished: *ttl,
+		CaptureWindow:           *captureWindow,
+		Image:                   *image,
+	}); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -3,6 +3,7 @@ package agent
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/netobserv/gopipes/pkg/node"
@@ -10,6 +11,8 @@ import (
 	"github.com/netobserv/netobserv-ebpf-agent/pkg/exporter"
 	"github.com/netobserv/netobserv-ebpf-agent/pkg/flow"
 	"github.com/netobserv/netobserv-ebpf-agent/pkg/ifaces"
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/metrics"
+	"github.com/segmentio/kafka-go/sasl"
 	"github.com/sirupsen/logrus"
 )
 
@@ -19,16 +22,32 @@ var alog = logrus.WithField("component", "agent.Flows")
 type Flows struct {
 	// trMutex provides synchronized access to the tracers map
 	trMutex sync.Mutex
-	// tracers stores a flowTracer implementation for each interface in the system, with a
-	// cancel function that allows stopping it when its interface is deleted
+	// tracers stores, for each interface in the system, its own tracer/accounter sub-pipeline
+	// with the cancel function that tears it down when its interface is deleted
 	tracers    map[ifaces.Name]cancellableTracer
-	accounter  *flow.Accounter
 	exporter   flowExporter
 	interfaces ifaces.Informer
 	filter     interfaceFilter
-	// tracerFactory specifies how to instantiate flowTracer implementations
+	// tracerFactory specifies how to instantiate flowTracer implementations.
+	//
+	// FlowsAgent always sets this to build a plain ebpf.NewFlowTracer, never a
+	// flow.NewMapTracer(fetcher, ...) over a constructed ebpf.FlowFetcher. That means the
+	// aggregated_flows map path -- adaptive eviction, batch lookup/delete, the single-value
+	// shadow-map rework, and the L7/drop-reason/service-mapping enrichment MapTracer merges in
+	// (see flow.MapTracer and its mapFetcher) -- is never reached in the running agent: the
+	// deduper's L7/DropReason/DestinationServicePort merge logic (pkg/flow/deduper.go) never
+	// receives a non-nil value for any of those fields in production, only in tests that
+	// construct a MapTracer directly.
 	tracerFactory func(name string, sampling uint32) flowTracer
-	cfg           *Config
+	// cfgMu guards cfg and filter against concurrent reads (from onInterfaceAdded) and writes
+	// (from Reload), so a hot reload can't race with a new interface being picked up.
+	cfgMu sync.RWMutex
+	cfg   *Config
+	// runCtx and exportRecordsCh are the context and fan-in channel interfacesManager set up at
+	// startup, kept around so Reload can re-register tracers (e.g. after a Sampling change) the
+	// same way onInterfaceAdded does. Both are only valid once Run has been called.
+	runCtx          context.Context
+	exportRecordsCh chan []*flow.Record
 }
 
 // flowTracer abstracts the interface of ebpf.FlowTracer to allow dependency injection in tests
@@ -38,9 +57,21 @@ type flowTracer interface {
 	Unregister() error
 }
 
+// cancellableTracer is one interface's whole sub-pipeline: its own flowTracer feeding its own
+// Accounter, which in turn forwards evicted flows into the shared exportRecordsCh fan-in. Each
+// one is started from scratch on onInterfaceAdded and torn down independently on
+// onInterfaceDeleted, which is what lets tracers come and go with the interfaces they watch
+// instead of requiring gopipes to add/remove nodes from the already-running graph (it doesn't
+// support that, see the note in processRecords).
 type cancellableTracer struct {
-	tracer flowTracer
+	tracer    flowTracer
+	accounter *flow.Accounter
+	// cancel stops both the tracer's Trace loop and its Accounter's Account loop: they share a
+	// context, so tearing one down always tears down the other with it.
 	cancel context.CancelFunc
+	// done is closed once Account has returned after cancel, so callers can wait for it before
+	// calling accounter.Drain, at which point nothing else can still be writing to entries.
+	done <-chan struct{}
 }
 
 // flowExporter abstract the ExportFlows' method of exporter.GRPCProto to allow dependency injection
@@ -73,17 +104,14 @@ func FlowsAgent(cfg *Config) (*Flows, error) {
 		informer = ifaces.NewWatcher(cfg.BuffersLength)
 	}
 
-	// configure GRPC+Protobuf exporter
-	target := fmt.Sprintf("%s:%d", cfg.TargetHost, cfg.TargetPort)
-	grpcExporter, err := exporter.StartGRPCProto(target)
+	flowExport, err := buildExporter(cfg)
 	if err != nil {
 		return nil, err
 	}
 
 	return &Flows{
 		tracers:    map[ifaces.Name]cancellableTracer{},
-		accounter:  flow.NewAccounter(cfg.CacheMaxFlows, cfg.BuffersLength, cfg.CacheActiveTimeout),
-		exporter:   grpcExporter.ExportFlows,
+		exporter:   flowExport,
 		interfaces: informer,
 		filter:     filter,
 		tracerFactory: func(name string, sampling uint32) flowTracer {
@@ -93,18 +121,174 @@ func FlowsAgent(cfg *Config) (*Flows, error) {
 	}, nil
 }
 
-// Run a Flows agent. The function will keep running in the same thread
-// until the passed context is canceled
+// buildExporter instantiates the flowExporter matching the cfg.Export protocol. It defaults to
+// the GRPC+Protobuf exporter when the value is empty or unrecognized.
+func buildExporter(cfg *Config) (flowExporter, error) {
+	switch cfg.Export {
+	case "kafka":
+		var saslMechanism sasl.Mechanism
+		if cfg.KafkaEnableSASL {
+			var err error
+			saslMechanism, err = exporter.BuildKafkaSASLMechanism(exporter.KafkaSASLConfig{
+				Type:                  cfg.KafkaSASLType,
+				ClientIDPath:          cfg.KafkaSASLClientIDPath,
+				ClientSecretPath:      cfg.KafkaSASLClientSecretPath,
+				OAuthTokenURL:         cfg.KafkaSASLOAuthTokenURL,
+				OAuthClientIDPath:     cfg.KafkaSASLOAuthClientIDPath,
+				OAuthClientSecretPath: cfg.KafkaSASLOAuthClientSecretPath,
+				OAuthScopes:           cfg.KafkaSASLOAuthScopes,
+			})
+			if err != nil {
+				return nil, fmt.Errorf("configuring Kafka SASL: %w", err)
+			}
+		}
+		// KafkaProtocolVersion isn't wired in here: unlike Sarama, kafka-go auto-negotiates the
+		// wire protocol from the brokers' ApiVersions response, so there's no version to pin.
+		writer, err := exporter.NewKafkaWriter(exporter.KafkaWriterConfig{
+			Brokers:        cfg.KafkaBrokers,
+			Topic:          cfg.KafkaTopic,
+			PartitionKey:   cfg.KafkaPartitionKey,
+			Partitioner:    cfg.KafkaPartitioner,
+			Compression:    cfg.KafkaCompression,
+			RequiredAcks:   cfg.KafkaRequiredAcks,
+			BatchSize:      cfg.KafkaBatchMessages,
+			BatchBytes:     cfg.KafkaBatchBytes,
+			ClientID:       cfg.KafkaClientID,
+			SASLMechanism:  saslMechanism,
+			Async:          cfg.KafkaAsync,
+			Idempotent:     cfg.KafkaIdempotent,
+			MaxInFlight:    cfg.KafkaMaxInFlight,
+			RequestTimeout: cfg.KafkaRequestTimeout,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("configuring Kafka exporter: %w", err)
+		}
+		switch cfg.KafkaEncoding {
+		case "", "protobuf":
+			kafkaExporter := &exporter.KafkaProto{Writer: writer, PartitionKey: cfg.KafkaPartitionKey, AgentIP: cfg.AgentIP}
+			// Wrapping only this (default) encoding in a RetryQueue, since KafkaProto is the one
+			// exporter in this switch with a Submit method returning a per-batch error for the
+			// queue to retry against; KafkaJSON/KafkaAvro and the GRPC+Protobuf default below
+			// would need the same treatment to get the same guarantee, but that's left for a
+			// follow-up rather than bundled into this change.
+			numConsumers := cfg.ExporterQueueNumConsumers
+			if cfg.KafkaMaxInFlight > 0 && (numConsumers == 0 || numConsumers > cfg.KafkaMaxInFlight) {
+				numConsumers = cfg.KafkaMaxInFlight
+			}
+			queue := exporter.NewRetryQueue(exporter.RetryQueueConfig{
+				QueueSize:            cfg.ExporterQueueSize,
+				NumConsumers:         numConsumers,
+				RetryEnabled:         cfg.ExporterRetryEnabled,
+				RetryInitialInterval: cfg.ExporterRetryInitialInterval,
+				RetryMaxInterval:     cfg.ExporterRetryMaxInterval,
+				RetryMaxElapsedTime:  cfg.ExporterRetryMaxElapsedTime,
+			}, kafkaExporter.Submit, metrics.NewMetrics(&metrics.Settings{}))
+			return queue.ExportFlows, nil
+		case "json":
+			kafkaExporter := &exporter.KafkaJSON{Writer: writer, PartitionKey: cfg.KafkaPartitionKey, AgentIP: cfg.AgentIP}
+			return kafkaExporter.ExportFlows, nil
+		case "avro":
+			// KafkaAvro needs a concrete exporter.AvroEncoder (an Avro codec generated from
+			// cfg.KafkaAvroSchema), which this agent doesn't vendor. Operators wiring up Avro
+			// must build their own binary importing pkg/exporter and providing one.
+			return nil, fmt.Errorf("kafka encoding %q requires a caller-supplied exporter.AvroEncoder; see pkg/exporter.KafkaAvro", cfg.KafkaEncoding)
+		default:
+			return nil, fmt.Errorf("unsupported kafka encoding %q", cfg.KafkaEncoding)
+		}
+	case "ipfix+udp", "ipfix+tcp":
+		network := "udp"
+		if cfg.Export == "ipfix+tcp" {
+			network = "tcp"
+		}
+		target := fmt.Sprintf("%s:%d", cfg.IPFIXTargetHost, cfg.IPFIXTargetPort)
+		ipfixExporter, err := exporter.StartIPFIXProto(network, target, cfg.IPFIXObservationDomain, cfg.IPFIXTemplateInterval)
+		if err != nil {
+			return nil, err
+		}
+		return ipfixExporter.ExportFlows, nil
+	case "otlp", "otlp+grpc", "otlp+http":
+		otlpExporter, err := exporter.NewOTLPProto(exporter.OTLPConfig{
+			Endpoint:              cfg.OTLPEndpoint,
+			Headers:               parseOTLPHeaders(cfg.OTLPHeaders),
+			Compression:           cfg.OTLPCompression,
+			Timeout:               cfg.OTLPTimeout,
+			Encoding:              cfg.OTLPEncoding,
+			AgentIP:               cfg.AgentIP,
+			TLSEnable:             cfg.OTLPEnableTLS,
+			TLSInsecureSkipVerify: cfg.OTLPTLSInsecureSkipVerify,
+			TLSCACertPath:         cfg.OTLPTLSCACertPath,
+			TLSUserCertPath:       cfg.OTLPTLSUserCertPath,
+			TLSUserKeyPath:        cfg.OTLPTLSUserKeyPath,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("configuring OTLP exporter: %w", err)
+		}
+		return otlpExporter.ExportFlows, nil
+	default:
+		target := fmt.Sprintf("%s:%d", cfg.TargetHost, cfg.TargetPort)
+		grpcExporter, err := exporter.StartGRPCProto(target)
+		if err != nil {
+			return nil, err
+		}
+		return grpcExporter.ExportFlows, nil
+	}
+}
+
+// parseOTLPHeaders splits a comma-separated "k=v,k2=v2" list (Config.OTLPHeaders) into a map,
+// ignoring entries that don't contain an "=".
+func parseOTLPHeaders(raw string) map[string]string {
+	if raw == "" {
+		return nil
+	}
+	headers := map[string]string{}
+	for _, pair := range strings.Split(raw, ",") {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			continue
+		}
+		headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+	}
+	return headers
+}
+
+// Run a Flows agent. The function will keep running in the same thread until the passed
+// context is canceled, unless cfg.CaptureJobMode is set, in which case it instead runs a
+// bounded capture (see RunCaptureJob) and returns once that capture's Window/MaxFlows bound
+// is hit, without requiring the caller to branch on CaptureJobMode itself.
 func (f *Flows) Run(ctx context.Context) error {
+	if f.cfg.CaptureJobMode {
+		if code := RunCaptureJob(ctx, f, CaptureJobConfig{
+			Enabled:  true,
+			Window:   f.cfg.CaptureWindow,
+			MaxFlows: f.cfg.CaptureMaxFlows,
+		}); code != 0 {
+			return fmt.Errorf("capture job exited with code %d", code)
+		}
+		return nil
+	}
+	return f.runLoop(ctx)
+}
+
+// runLoop is the usual long-running Flows pipeline: it keeps running until ctx is canceled.
+// Both Run and RunCaptureJob (which bounds ctx itself before delegating here) funnel into it,
+// so the capture-job bound is applied exactly once regardless of which one is the caller.
+func (f *Flows) runLoop(ctx context.Context) error {
 	alog.Info("starting Flows agent")
 
 	systemSetup()
 
-	tracedRecords, err := f.interfacesManager(ctx)
+	f.runCtx = ctx
+	exportRecords, err := f.interfacesManager(ctx)
 	if err != nil {
 		return err
 	}
-	graph := f.processRecords(tracedRecords)
+	graph := f.processRecords(exportRecords)
+
+	if f.cfg.ConfigOverlayPath != "" {
+		alog.WithField("path", f.cfg.ConfigOverlayPath).Info("watching config overlay for hot reload")
+		watcher := NewConfigWatcher(f.cfg.ConfigOverlayPath, f.cfg.ConfigOverlayPollInterval)
+		go watcher.Watch(ctx, f.Reload)
+	}
 
 	alog.Info("Flows agent successfully started")
 	<-ctx.Done()
@@ -118,8 +302,9 @@ func (f *Flows) Run(ctx context.Context) error {
 }
 
 // interfacesManager uses an informer to check new/deleted network interfaces. For each running
-// interface, it registers a flow tracer that will forward new flows to the returned channel
-func (f *Flows) interfacesManager(ctx context.Context) (<-chan *flow.Record, error) {
+// interface, it starts its own tracer+accounter sub-pipeline (see cancellableTracer) that
+// forwards its evicted flows into the returned channel.
+func (f *Flows) interfacesManager(ctx context.Context) (<-chan []*flow.Record, error) {
 	slog := alog.WithField("function", "interfacesManager")
 
 	slog.Debug("subscribing for network interface events")
@@ -128,7 +313,8 @@ func (f *Flows) interfacesManager(ctx context.Context) (<-chan *flow.Record, err
 		return nil, fmt.Errorf("instantiating interfaces' informer: %w", err)
 	}
 
-	tracedRecords := make(chan *flow.Record, f.cfg.BuffersLength)
+	exportRecords := make(chan []*flow.Record, f.cfg.BuffersLength)
+	f.exportRecordsCh = exportRecords
 	go func() {
 		for {
 			select {
@@ -136,13 +322,13 @@ func (f *Flows) interfacesManager(ctx context.Context) (<-chan *flow.Record, err
 				slog.Debug("detaching all the flow tracers before closing the records' channel")
 				f.detachAllTracers()
 				slog.Debug("closing channel and exiting internal goroutine")
-				close(tracedRecords)
+				close(exportRecords)
 				return
 			case event := <-ifaceEvents:
 				slog.WithField("event", event).Debug("received event")
 				switch event.Type {
 				case ifaces.EventAdded:
-					f.onInterfaceAdded(ctx, event.Interface, tracedRecords)
+					f.onInterfaceAdded(ctx, event.Interface)
 				case ifaces.EventDeleted:
 					f.onInterfaceDeleted(event.Interface)
 				default:
@@ -152,35 +338,47 @@ func (f *Flows) interfacesManager(ctx context.Context) (<-chan *flow.Record, err
 		}
 	}()
 
-	return tracedRecords, nil
+	return exportRecords, nil
 }
 
-// processRecords creates the tracers --> accounter --> forwarder Flow processing graph
-func (f *Flows) processRecords(tracedRecords <-chan *flow.Record) *node.Terminal {
-	// The start node receives Records from the eBPF flow tracers. Currently it is just an external
-	// channel forwarder, as the Pipes library does not yet accept
-	// adding/removing nodes dynamically: https://github.com/mariomac/pipes/issues/5
-	alog.Debug("registering tracers' input")
-	tracersCollector := node.AsInit(func(out chan<- *flow.Record) {
-		for i := range tracedRecords {
-			out <- i
+// processRecords creates the forwarder --> exporter Flow processing graph. Each interface's own
+// tracer+accounter sub-pipeline already does its own accounting (see onInterfaceAdded) and writes
+// its evicted flows straight into exportRecords, so the only part of the graph gopipes itself
+// needs to own is this fan-in into the exporter: the one part of the graph that's never added to
+// or removed from while it's running. Everything upstream of it is added/removed per-interface
+// instead, since the Pipes library does not accept adding/removing nodes dynamically:
+// https://github.com/mariomac/pipes/issues/5
+func (f *Flows) processRecords(exportRecords <-chan []*flow.Record) *node.Terminal {
+	alog.Debug("registering exporter's input")
+	exportCollector := node.AsInit(func(out chan<- []*flow.Record) {
+		for batch := range exportRecords {
+			out <- batch
 		}
 	})
-	alog.Debug("registering accounter")
-	accounter := node.AsMiddle(f.accounter.Account)
 	alog.Debug("registering exporter")
 	export := node.AsTerminal(f.exporter)
 	alog.Debug("connecting graph")
-	tracersCollector.SendsTo(accounter)
-	accounter.SendsTo(export)
+	exportCollector.SendsTo(export)
 	alog.Debug("starting graph")
-	tracersCollector.Start()
+	exportCollector.Start()
 	return export
 }
 
-func (f *Flows) onInterfaceAdded(ctx context.Context, name ifaces.Name, flowsCh chan *flow.Record) {
+// onInterfaceAdded starts a brand new tracer+accounter sub-pipeline for name: its own flowTracer,
+// registered and traced under its own cancellable context, feeding its own Accounter, which
+// evicts straight into the shared exportRecordsCh fan-in (see processRecords). Giving each
+// interface its own Accounter, instead of sharing one across all of them, is also what makes it
+// possible to drain exactly that interface's pending flows on onInterfaceDeleted without
+// disturbing any other interface's accounting.
+func (f *Flows) onInterfaceAdded(ctx context.Context, name ifaces.Name) {
+	f.cfgMu.RLock()
+	allowed := f.filter.Allowed(name)
+	sampling := f.cfg.Sampling
+	cacheMaxFlows := f.cfg.CacheMaxFlows
+	cacheActiveTimeout := f.cfg.CacheActiveTimeout
+	f.cfgMu.RUnlock()
 	// ignore interfaces that do not match the user configuration acceptance/exclusion lists
-	if !f.filter.Allowed(name) {
+	if !allowed {
 		alog.WithField("name", name).
 			Debug("interface does not match the allow/exclusion filters. Ignoring")
 		return
@@ -189,38 +387,79 @@ func (f *Flows) onInterfaceAdded(ctx context.Context, name ifaces.Name, flowsCh
 	defer f.trMutex.Unlock()
 	if _, ok := f.tracers[name]; !ok {
 		alog.WithField("name", name).Info("interface detected. Registering flow tracer")
-		tracer := f.tracerFactory(string(name), f.cfg.Sampling)
+		tracer := f.tracerFactory(string(name), sampling)
 		if err := tracer.Register(); err != nil {
 			alog.WithField("interface", name).WithError(err).
 				Warn("can't register flow tracer. Ignoring")
 			return
 		}
 		tctx, cancel := context.WithCancel(ctx)
-		go tracer.Trace(tctx, flowsCh)
+		accounter := flow.NewAccounter(string(name), cacheMaxFlows, cacheActiveTimeout)
+		// recordsCh is unbuffered-in-spirit at f.cfg.BuffersLength: it only ever has one writer
+		// (this interface's Trace loop) and one reader (its Account loop), so there's no
+		// fan-in/fan-out to size for beyond smoothing out bursts the same way tracedRecords used to.
+		recordsCh := make(chan *flow.Record, f.cfg.BuffersLength)
+		done := make(chan struct{})
+		go tracer.Trace(tctx, recordsCh)
+		go func() {
+			accounter.Account(tctx, recordsCh, f.exportRecordsCh)
+			close(done)
+		}()
 		f.tracers[name] = cancellableTracer{
-			tracer: tracer,
-			cancel: cancel,
+			tracer:    tracer,
+			accounter: accounter,
+			cancel:    cancel,
+			done:      done,
 		}
 	}
 }
 
 func (f *Flows) onInterfaceDeleted(name ifaces.Name) {
 	f.trMutex.Lock()
-	defer f.trMutex.Unlock()
-	if ft, ok := f.tracers[name]; ok {
-		alog.WithField("name", name).Info("interface deleted. Removing flow tracer")
-		ft.cancel()
+	ft, ok := f.tracers[name]
+	if ok {
 		delete(f.tracers, name)
-		// qdiscs, ingress and egress filters are automatically deleted so we don't need to
-		// specifically detach the tracer
+	}
+	f.trMutex.Unlock()
+	if !ok {
+		return
+	}
+	alog.WithField("name", name).Info("interface deleted. Removing flow tracer")
+	// qdiscs, ingress and egress filters are automatically deleted so we don't need to
+	// specifically detach the tracer, but we do still need to drain its accounter: otherwise
+	// whatever flows it had accumulated but not yet evicted would be lost with it.
+	f.detachTracer(ft)
+}
+
+// detachTracer cancels ft's tracer and accounter (they share a context, see cancellableTracer),
+// waits for its Account loop to actually return, and only then drains whatever RecordMetrics it
+// had accumulated but not yet evicted and forwards them to the exporter fan-in. Waiting for done
+// first guarantees nothing else can still be writing to the accounter's entries when Drain reads
+// them, and that no flow observed on this interface is lost to the teardown.
+//
+// The send to exportRecordsCh is unconditional, not raced against f.runCtx: both callers
+// (onInterfaceDeleted and detachAllTracers) only ever invoke detachTracer while the
+// interfacesManager goroutine is still alive to read from exportRecordsCh, including the
+// ctx.Done() shutdown path, which drains every tracer before it closes the channel. Selecting
+// on f.runCtx.Done() here as well as the send would let Go's random case selection throw away a
+// drained batch about half the time during shutdown, exactly when every flow is supposed to be
+// offered to the exporter.
+func (f *Flows) detachTracer(ft cancellableTracer) {
+	ft.cancel()
+	<-ft.done
+	if drained := ft.accounter.Drain(); len(drained) > 0 {
+		f.exportRecordsCh <- drained
 	}
 }
 
+// detachAllTracers cancels and drains every tracer's sub-pipeline before unregistering it, and
+// returns only once every one of them has finished draining, so Run doesn't return until every
+// flow observed on every interface has been offered to the exporter.
 func (f *Flows) detachAllTracers() {
 	f.trMutex.Lock()
 	defer f.trMutex.Unlock()
 	for name, ft := range f.tracers {
-		ft.cancel()
+		f.detachTracer(ft)
 		flog := alog.WithField("name", name)
 		flog.Info("unregistering flow tracer")
 		if err := ft.tracer.Unregister(); err != nil {
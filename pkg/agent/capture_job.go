@@ -0,0 +1,111 @@
+package agent
+
+import (
+	"context"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/flow"
+)
+
+const (
+	// JobCompletionIndexEnvVar is the downward-API environment variable that the Job
+	// controller injects into every Pod of an Indexed Job.
+	JobCompletionIndexEnvVar = "JOB_COMPLETION_INDEX"
+
+	// ExitCodeCaptureFailed is returned by RunCaptureJob when the capture pipeline itself fails
+	// (runLoop returns an error, e.g. the interfaces' informer can't be subscribed to). It is
+	// distinct from 0 so that a Job spec can use podFailurePolicy.onExitCodes together with
+	// backoffLimitPerIndex to retry only the affected index, instead of the whole Job. Exporter
+	// errors and ring buffer overflows during the capture window are not detected and don't
+	// change the exit code.
+	ExitCodeCaptureFailed = 3
+)
+
+// CaptureJobConfig configures the time-boxed, one-shot capture mode used when the agent is run
+// as a Pod of a batch/v1 Job (typically with completionMode: Indexed) rather than as a
+// long-running DaemonSet.
+type CaptureJobConfig struct {
+	// Enabled switches the agent from its usual long-running Run loop to a bounded capture
+	// that exports and exits.
+	Enabled bool
+	// Window is the wall-clock duration the agent captures flows for, before exporting and
+	// exiting. Ignored if zero.
+	Window time.Duration
+	// MaxFlows bounds the capture by flow count instead of (or in addition to) wall-clock
+	// time. Ignored if zero.
+	MaxFlows int
+}
+
+// CompletionIndex reads this Pod's completion index from the downward API, as injected by the
+// Job controller for completionMode: Indexed Jobs.
+func CompletionIndex() (int, bool) {
+	v, ok := os.LookupEnv(JobCompletionIndexEnvVar)
+	if !ok {
+		return 0, false
+	}
+	idx, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, false
+	}
+	return idx, true
+}
+
+// RunCaptureJob runs the Flows agent for a bounded window and/or until MaxFlows records have
+// been exported, whichever bound is hit first, and returns the process exit code that the
+// caller should use: 0 on a successful capture, ExitCodeCaptureFailed otherwise.
+//
+// It calls f.runLoop rather than f.Run: Flows.Run already delegates to RunCaptureJob itself when
+// f.cfg.CaptureJobMode is set, so calling back into Run here would re-apply the bound on top of
+// itself instead of just running the pipeline once.
+func RunCaptureJob(ctx context.Context, f *Flows, cfg CaptureJobConfig) int {
+	runCtx := ctx
+	var cancel context.CancelFunc
+	if cfg.Window > 0 {
+		runCtx, cancel = context.WithTimeout(ctx, cfg.Window)
+		defer cancel()
+	}
+	if cfg.MaxFlows > 0 {
+		if cancel == nil {
+			runCtx, cancel = context.WithCancel(ctx)
+			defer cancel()
+		}
+		f.exporter = maxFlowsExporter(f.exporter, cfg.MaxFlows, cancel)
+	}
+	if err := f.runLoop(runCtx); err != nil {
+		alog.WithError(err).Error("capture job failed")
+		return ExitCodeCaptureFailed
+	}
+	return 0
+}
+
+// maxFlowsExporter wraps exporter so the capture Job's context is canceled as soon as maxFlows
+// records have been forwarded to it, bounding the capture by flow count the same way Window
+// bounds it by wall-clock time. Batches already in flight when the bound is hit are still
+// delivered to exporter; only batches received afterwards are dropped, since cancel is already
+// tearing the rest of the agent down at that point.
+func maxFlowsExporter(exporter flowExporter, maxFlows int, cancel context.CancelFunc) flowExporter {
+	return func(in <-chan []*flow.Record) {
+		inner := make(chan []*flow.Record)
+		done := make(chan struct{})
+		go func() {
+			exporter(inner)
+			close(done)
+		}()
+		var count int
+		reached := false
+		for batch := range in {
+			if !reached {
+				inner <- batch
+				count += len(batch)
+				if count >= maxFlows {
+					reached = true
+					cancel()
+				}
+			}
+		}
+		close(inner)
+		<-done
+	}
+}
@@ -0,0 +1,19 @@
+package agent
+
+import "fmt"
+
+// NodeSelectorForIndex deterministically maps a Job completion index to one of the cluster
+// nodes listed in nodes (typically provided via a ConfigMap mounted alongside the Job), so that
+// an N-node cluster produces exactly N indexed Pods, each pinned to a different node via the
+// returned node name. The caller is expected to set it as the Pod's nodeName or as a
+// "kubernetes.io/hostname" nodeSelector entry before the Pod is admitted (e.g. from a small
+// mutating webhook or an init step of the Job controller helper).
+func NodeSelectorForIndex(completionIndex int, nodes []string) (string, error) {
+	if len(nodes) == 0 {
+		return "", fmt.Errorf("no nodes available to pin completion index %d to", completionIndex)
+	}
+	if completionIndex < 0 || completionIndex >= len(nodes) {
+		return "", fmt.Errorf("completion index %d out of range for %d nodes", completionIndex, len(nodes))
+	}
+	return nodes[completionIndex], nil
+}
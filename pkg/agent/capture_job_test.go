@@ -0,0 +1,56 @@
+package agent
+
+import (
+	"sync/atomic"
+	"testing"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNodeSelectorForIndex(t *testing.T) {
+	nodes := []string{"node-a", "node-b", "node-c"}
+
+	node, err := NodeSelectorForIndex(1, nodes)
+	require.NoError(t, err)
+	assert.Equal(t, "node-b", node)
+
+	_, err = NodeSelectorForIndex(3, nodes)
+	assert.Error(t, err)
+
+	_, err = NodeSelectorForIndex(0, nil)
+	assert.Error(t, err)
+}
+
+func TestMaxFlowsExporter_CancelsOnceBoundReached(t *testing.T) {
+	var delivered int64
+	var canceled int32
+	exporter := maxFlowsExporter(
+		func(in <-chan []*flow.Record) {
+			for batch := range in {
+				atomic.AddInt64(&delivered, int64(len(batch)))
+			}
+		},
+		5,
+		func() { atomic.StoreInt32(&canceled, 1) },
+	)
+
+	in := make(chan []*flow.Record, 3)
+	done := make(chan struct{})
+	go func() {
+		exporter(in)
+		close(done)
+	}()
+
+	in <- []*flow.Record{{}, {}, {}}
+	in <- []*flow.Record{{}, {}}
+	in <- []*flow.Record{{}, {}}
+	close(in)
+	<-done
+
+	assert.EqualValues(t, 1, atomic.LoadInt32(&canceled))
+	// Only the first two batches (3+2=5) are forwarded: the bound is reached exactly on the
+	// second batch, and the third one arrives after that.
+	assert.EqualValues(t, 5, atomic.LoadInt64(&delivered))
+}
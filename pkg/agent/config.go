@@ -134,12 +134,33 @@ type Config struct {
 	KafkaTLSUserKeyPath string `env:"KAFKA_TLS_USER_KEY_PATH"`
 	// KafkaEnableSASL set true to enable SASL auth
 	KafkaEnableSASL bool `env:"KAFKA_ENABLE_SASL" envDefault:"false"`
-	// KafkaSASLType type of SASL mechanism: plain or scramSHA512
+	// KafkaSASLType type of SASL mechanism: plain, scramSHA512, scramSHA256 or oauthbearer
 	KafkaSASLType string `env:"KAFKA_SASL_TYPE" envDefault:"plain"`
-	// KafkaSASLClientIDPath is the path to the client ID (username) for SASL auth
+	// KafkaSASLClientIDPath is the path to the client ID (username) for SASL auth. Used by the
+	// plain and SCRAM mechanisms; ignored by oauthbearer (see KafkaSASLOAuthClientIDPath).
 	KafkaSASLClientIDPath string `env:"KAFKA_SASL_CLIENT_ID_PATH"`
-	// KafkaSASLClientSecretPath is the path to the client secret (password) for SASL auth
+	// KafkaSASLClientSecretPath is the path to the client secret (password) for SASL auth. Used
+	// by the plain and SCRAM mechanisms; ignored by oauthbearer.
 	KafkaSASLClientSecretPath string `env:"KAFKA_SASL_CLIENT_SECRET_PATH"`
+	// KafkaSASLOAuthTokenURL is the OAuth2 token endpoint the agent requests access tokens
+	// from, when KafkaSASLType is "oauthbearer".
+	KafkaSASLOAuthTokenURL string `env:"KAFKA_SASL_OAUTH_TOKEN_URL"`
+	// KafkaSASLOAuthClientIDPath is the path to the OAuth2 client ID used in the
+	// client-credentials grant, when KafkaSASLType is "oauthbearer".
+	KafkaSASLOAuthClientIDPath string `env:"KAFKA_SASL_OAUTH_CLIENT_ID_PATH"`
+	// KafkaSASLOAuthClientSecretPath is the path to the OAuth2 client secret used in the
+	// client-credentials grant, when KafkaSASLType is "oauthbearer".
+	KafkaSASLOAuthClientSecretPath string `env:"KAFKA_SASL_OAUTH_CLIENT_SECRET_PATH"`
+	// KafkaSASLOAuthScopes is a space-separated list of OAuth2 scopes requested alongside the
+	// client-credentials grant (per RFC 6749 §3.3), when KafkaSASLType is "oauthbearer".
+	KafkaSASLOAuthScopes string `env:"KAFKA_SASL_OAUTH_SCOPES"`
+	// KafkaClientID identifies this agent to the Kafka brokers, surfaced in broker-side request
+	// logging and quotas. Empty uses kafka-go's default.
+	KafkaClientID string `env:"KAFKA_CLIENT_ID"`
+	// KafkaProtocolVersion holds a Kafka wire protocol version, e.g. "2.8.0". The kafka exporter
+	// doesn't read it: kafka-go negotiates the protocol version from the brokers' ApiVersions
+	// response whatever this value is.
+	KafkaProtocolVersion string `env:"KAFKA_PROTOCOL_VERSION"`
 	// ProfilePort sets the listening port for Go's Pprof tool. If it is not set, profile is disabled
 	ProfilePort int `env:"PROFILE_PORT"`
 	// Flowlogs-pipeline configuration as YAML or JSON, used when export is "direct-flp". Cf https://github.com/netobserv/flowlogs-pipeline
@@ -174,4 +195,213 @@ type Config struct {
 	MetricsPort int `env:"METRICS_SERVER_PORT" envDefault:"9090"`
 	// MetricsPrefix is the prefix of the metrics that are sent to the server.
 	MetricsPrefix string `env:"METRICS_PREFIX" envDefault:"ebpf_agent_"`
+	// EnableL7Tracking is meant to enable the Layer-7 protocol visibility hook (HTTP/DNS/TLS-SNI)
+	// that decorates flows with application-level fields, default is false (disabled).
+	// Individual Pods/Namespaces can override this default via the "flows.netobserv.io/
+	// l7-visibility" annotation when EnableWorkloadAnnotations is also set, but that override has
+	// nothing to turn on either: this build doesn't ship the generated BPF program that would
+	// parse HTTP/DNS/TLS-SNI out of the egress/ingress TC hooks (see ebpf.L7Records, the one
+	// piece of the read path that does exist), flow.Record has no AppProtocol/HttpMethod/
+	// HttpPath/HttpStatus/DnsQName/DnsRcode/TlsSNI fields, and no exporter schema carries them.
+	// Setting this and L7Direction parses cleanly and enables nothing.
+	EnableL7Tracking bool `env:"ENABLE_L7_TRACKING" envDefault:"false"`
+	// L7Direction restricts the Layer-7 parsing to a traffic direction, to limit the CPU cost
+	// of inspecting payloads. Accepted values are "ingress", "egress" or "both" (default). Same
+	// caveat as EnableL7Tracking: there is no Layer-7 parsing for this to restrict yet.
+	L7Direction string `env:"L7_DIRECTION" envDefault:"both"`
+	// EnableWorkloadAnnotations is meant to enable per-Pod/Namespace flow capture and sampling
+	// overrides, driven by the "flows.netobserv.io/capture" and "flows.netobserv.io/sampling"
+	// annotations. workloadFilter.Reconcile implements the annotation-resolution logic, but
+	// nothing in pkg/agent constructs a Pod/Namespace informer to feed it or calls it from
+	// onInterfaceAdded/onInterfaceDeleted, since that requires a Kubernetes API client this
+	// agent doesn't currently depend on. Setting this parses cleanly and has no effect yet.
+	EnableWorkloadAnnotations bool `env:"ENABLE_WORKLOAD_ANNOTATIONS" envDefault:"false"`
+	// EnableOVNEnrichment is meant to enable the optional OVN/OVSDB enrichment stage that
+	// decorates flows with logical topology fields (LogicalSwitch, LogicalPort, LogicalRouter,
+	// LSPType). Nothing in pkg/agent constructs an enricher.OVNEnricher yet, and no flow.Record
+	// or exporter schema carries those fields, so setting this parses cleanly and has no effect
+	// until that enrichment stage is wired in.
+	EnableOVNEnrichment bool `env:"ENABLE_OVN_ENRICHMENT" envDefault:"false"`
+	// OVNDBEndpoint is the OVSDB connection string of the database to monitor, e.g.
+	// "tcp:127.0.0.1:6642" or "ssl:127.0.0.1:6642". Same caveat as EnableOVNEnrichment: unused
+	// until the OVN enrichment stage is wired in.
+	OVNDBEndpoint string `env:"OVN_DB_ENDPOINT" envDefault:"tcp:127.0.0.1:6642"`
+	// OVNDatabase selects which OVSDB database to monitor: "OVN_Southbound" (default) or
+	// "OVN_Northbound".
+	OVNDatabase string `env:"OVN_DATABASE" envDefault:"OVN_Southbound"`
+	// IPFIXTargetHost is the host name or IP of the IPFIX collector, when Export is set to
+	// "ipfix+udp" or "ipfix+tcp".
+	IPFIXTargetHost string `env:"IPFIX_TARGET_HOST"`
+	// IPFIXTargetPort is the port of the IPFIX collector, when Export is set to "ipfix+udp" or
+	// "ipfix+tcp".
+	IPFIXTargetPort int `env:"IPFIX_TARGET_PORT"`
+	// IPFIXObservationDomain sets the Observation Domain ID included in every IPFIX message.
+	IPFIXObservationDomain uint32 `env:"IPFIX_OBSERVATION_DOMAIN" envDefault:"1"`
+	// IPFIXTemplateInterval sets how often the IPFIX template set is resent to the collector.
+	IPFIXTemplateInterval time.Duration `env:"IPFIX_TEMPLATE_INTERVAL" envDefault:"10s"`
+	// EnableJobEnrichment is meant to enable resolving the Job/CronJob owner of a flow's Pod
+	// endpoints (SrcK8S_JobName, SrcK8S_CronJobName, SrcK8S_JobCompletionIndex, ...), but nothing
+	// in pkg/agent constructs an enricher.JobOwnerCache or calls ResolveJobOwner yet, so setting
+	// this parses cleanly and has no effect until that enrichment stage is wired in.
+	EnableJobEnrichment bool `env:"ENABLE_JOB_ENRICHMENT" envDefault:"false"`
+	// JobManagedByFilter, when set, is meant to restrict the Job informer cache to Jobs whose
+	// "spec.managedBy" matches this value (e.g. a Kueue controller name), so operators don't pay
+	// the memory cost of watching Jobs they don't care about. Empty means watch all Jobs. Same
+	// caveat as EnableJobEnrichment: unused until the Job informer cache is wired in.
+	JobManagedByFilter string `env:"JOB_MANAGED_BY_FILTER"`
+	// CaptureJobMode runs the agent as a bounded, one-shot capture (export then exit) instead
+	// of the usual long-running loop, for use as a batch/v1 Job Pod.
+	CaptureJobMode bool `env:"CAPTURE_JOB_MODE" envDefault:"false"`
+	// CaptureWindow is the wall-clock duration a CaptureJobMode run captures flows for.
+	CaptureWindow time.Duration `env:"CAPTURE_WINDOW" envDefault:"60s"`
+	// CaptureMaxFlows bounds a CaptureJobMode run by flow count. 0 disables the bound.
+	CaptureMaxFlows int `env:"CAPTURE_MAX_FLOWS" envDefault:"0"`
+	// SnapshotSchedule, when set, is meant to enable the CronJob-driven scheduled snapshot
+	// subsystem (SnapshotScheduler): a standard 5-field cron expression describing when to open
+	// a new capture window. Nothing in pkg/agent constructs a SnapshotScheduler from this yet --
+	// Flows.Run never checks it -- and flow.Record/the exporter schemas have no SnapshotID field
+	// for a WindowFunc to tag flows with, other than the one already reserved on
+	// exporter.KafkaJSON/KafkaAvro's JSONRecord. Setting this and the SnapshotTimeZone/
+	// SnapshotConcurrencyPolicy/SnapshotStartingDeadline/SnapshotWindow fields below parses
+	// cleanly and has no effect until that wiring exists.
+	SnapshotSchedule string `env:"SNAPSHOT_SCHEDULE"`
+	// SnapshotTimeZone is the IANA time zone the SnapshotSchedule is evaluated in. Empty means
+	// UTC. Same caveat as SnapshotSchedule: unused until a SnapshotScheduler is wired in.
+	SnapshotTimeZone string `env:"SNAPSHOT_TIME_ZONE"`
+	// SnapshotConcurrencyPolicy is one of "Allow" (default), "Forbid" or "Replace", applied
+	// when a previous snapshot window is still exporting when the next one fires. Same caveat
+	// as SnapshotSchedule: unused until a SnapshotScheduler is wired in.
+	SnapshotConcurrencyPolicy string `env:"SNAPSHOT_CONCURRENCY_POLICY" envDefault:"Allow"`
+	// SnapshotStartingDeadline bounds how late a missed firing may still start. 0 disables it.
+	// Same caveat as SnapshotSchedule: unused until a SnapshotScheduler is wired in.
+	SnapshotStartingDeadline time.Duration `env:"SNAPSHOT_STARTING_DEADLINE"`
+	// SnapshotWindow is the duration of each scheduled capture window. Same caveat as
+	// SnapshotSchedule: unused until a SnapshotScheduler is wired in.
+	SnapshotWindow time.Duration `env:"SNAPSHOT_WINDOW" envDefault:"30s"`
+	// KafkaPartitionKey selects the partitioning strategy applied to outgoing Kafka messages, so
+	// that records from the same flow (or conversation) are routed to the same partition and
+	// keep their relative order at the Flowlogs-Pipeline collector. Accepted values are "none"
+	// (default), "fiveTuple" (alias "5tuple"), "srcIP", "dstIP", "conversationHash" (alias
+	// "srcDstIP"; src/dst canonicalized so both directions of a conversation hash the same),
+	// "flowid" (fiveTuple plus interface and direction), "interface" or "agentIP".
+	KafkaPartitionKey string `env:"KAFKA_PARTITION_KEY" envDefault:"none"`
+	// KafkaPartitioner selects the kafkago.Balancer used to route a keyed (or unkeyed) message
+	// to a partition. Accepted values are "" (default: "hash" when KafkaPartitionKey is set,
+	// "leastBytes" otherwise), "hash", "roundrobin", "murmur2" (matches the Java/Sarama default
+	// partitioner, for co-partitioning with consumers on those clients) or "manual" (rejected:
+	// this exporter never sets an explicit partition on outgoing messages).
+	KafkaPartitioner string `env:"KAFKA_PARTITIONER"`
+	// KafkaBatchBytes is the maximum size, in bytes, of a Kafka writer batch. The kafka exporter
+	// takes its byte limit from this field and its message-count limit from KafkaBatchMessages;
+	// it doesn't read KafkaBatchSize.
+	KafkaBatchBytes int64 `env:"KAFKA_BATCH_BYTES" envDefault:"1048576"`
+	// KafkaRequiredAcks is the acknowledgement level required from the Kafka brokers before a
+	// batch is considered sent. Accepted values are "none", "one" (default) or "all".
+	KafkaRequiredAcks string `env:"KAFKA_REQUIRED_ACKS" envDefault:"one"`
+	// KafkaEncoding selects the wire encoding used by the "kafka" exporter. Accepted values are
+	// "protobuf" (default, understood natively by Flowlogs-Pipeline), "json" (JSONRecord,
+	// omitempty-encoded) or "avro" (Confluent Schema Registry wire format).
+	KafkaEncoding string `env:"KAFKA_ENCODING" envDefault:"protobuf"`
+	// KafkaSchemaRegistryURL is the base URL of the Confluent Schema Registry used to
+	// register/fetch the schema ID when KafkaEncoding is "avro".
+	KafkaSchemaRegistryURL string `env:"KAFKA_SCHEMA_REGISTRY_URL"`
+	// KafkaAvroSchemaSubject is the Schema Registry subject the flow Avro schema is registered
+	// under, conventionally "<topic>-value".
+	KafkaAvroSchemaSubject string `env:"KAFKA_AVRO_SCHEMA_SUBJECT"`
+	// KafkaAvroSchema is the Avro schema (JSON) to register under KafkaAvroSchemaSubject if it
+	// doesn't already exist there.
+	KafkaAvroSchema string `env:"KAFKA_AVRO_SCHEMA"`
+	// KafkaIdempotent requests the strongest delivery guarantee this writer can give: every
+	// broker in the ISR must ack a batch before WriteMessages returns (it forces
+	// KafkaRequiredAcks to "all" regardless of its configured value). It does not give full
+	// exactly-once/duplicate-suppression semantics, since kafka-go doesn't implement the
+	// broker-side producer sequence-number protocol behind Sarama/librdkafka's
+	// enable.idempotence.
+	KafkaIdempotent bool `env:"KAFKA_IDEMPOTENT" envDefault:"false"`
+	// KafkaMaxInFlight bounds how many batches the "kafka" exporter's retry queue will submit to
+	// the brokers concurrently: when set, the queue runs that many consumers if
+	// ExporterQueueNumConsumers is 0, and at most that many otherwise. 0 (default) sets no bound,
+	// leaving ExporterQueueNumConsumers (1 by default) in charge.
+	KafkaMaxInFlight int `env:"KAFKA_MAX_IN_FLIGHT"`
+	// KafkaRequestTimeout bounds how long a single batch write waits for the brokers to ack
+	// before failing it.
+	KafkaRequestTimeout time.Duration `env:"KAFKA_REQUEST_TIMEOUT" envDefault:"10s"`
+	// ExporterQueueSize is the number of encoded flow batches the exporter's retry queue buffers
+	// ahead of the collector, so a slow or briefly unreachable collector doesn't block the
+	// tracer/accounter pipeline. 0 (default) means 1000.
+	ExporterQueueSize int `env:"EXPORTER_QUEUE_SIZE"`
+	// ExporterQueueNumConsumers is how many goroutines concurrently drain the exporter retry
+	// queue. 0 (default) means 1.
+	ExporterQueueNumConsumers int `env:"EXPORTER_QUEUE_NUM_CONSUMERS"`
+	// ExporterRetryEnabled enables retrying a failed batch, with capped exponential backoff,
+	// instead of dropping it on the first error.
+	ExporterRetryEnabled bool `env:"EXPORTER_RETRY_ENABLED" envDefault:"true"`
+	// ExporterRetryInitialInterval is the delay before the first retry of a failed batch.
+	ExporterRetryInitialInterval time.Duration `env:"EXPORTER_RETRY_INITIAL_INTERVAL" envDefault:"1s"`
+	// ExporterRetryMaxInterval caps the exponentially-growing delay between retries.
+	ExporterRetryMaxInterval time.Duration `env:"EXPORTER_RETRY_MAX_INTERVAL" envDefault:"30s"`
+	// ExporterRetryMaxElapsedTime bounds how long a single batch is retried before it's dropped.
+	// 0 (default) means retry forever.
+	ExporterRetryMaxElapsedTime time.Duration `env:"EXPORTER_RETRY_MAX_ELAPSED_TIME"`
+	// CacheMaxFillRatio holds a high watermark, as a fraction (0..1) of the aggregated-flows
+	// map's MaxEntries. Nothing in pkg/agent reads it: pkg/agent builds no flow.MapTracer, and a
+	// MapTracer keeps its default watermarks (0.75 high, 0.9 critical) unless WithWatermarks is
+	// called. Within MapTracer only the critical watermark forces an eviction; reaching the high
+	// one is only logged.
+	CacheMaxFillRatio float64 `env:"CACHE_MAX_FILL_RATIO" envDefault:"0.75"`
+	// Probes is meant to enable additional eBPF probes, attached on top of the flow-parse
+	// ingress/egress programs, by name. Accepted values are "tcp_retransmit",
+	// "tcp_drops_reason", "tcp_rtt" and "queue_latency" (cf. ebpf.ProbeRegistry). Nothing in
+	// pkg/agent constructs an ebpf.ProbeRegistry or reads this field yet -- FlowsAgent's
+	// tracerFactory only ever builds a plain ebpf.NewFlowTracer -- so setting this parses
+	// cleanly and attaches nothing until a FlowFetcher/ProbeRegistry pair is wired in.
+	Probes []string `env:"PROBES" envSeparator:","`
+	// EnableEndpointAccounting is meant to enable the lightweight per-endpoint (MAC/IP) bandwidth
+	// accounting path (flow.EndpointTracer), as a cheaper alternative to full 5-tuple flow
+	// Records for per-tenant usage reporting. Default is false (disabled). Nothing in pkg/agent
+	// constructs a flow.EndpointTracer yet, so setting this parses cleanly and has no effect
+	// until that tracer is wired into Flows.Run.
+	EnableEndpointAccounting bool `env:"ENABLE_ENDPOINT_ACCOUNTING" envDefault:"false"`
+	// EndpointAccountingPollInterval is how often flow.EndpointTracer would drain the
+	// "endpoint_bytes" BPF_MAP_TYPE_LRU_HASH map, when EnableEndpointAccounting is set. Same
+	// caveat as EnableEndpointAccounting: unused until that tracer is wired in.
+	EndpointAccountingPollInterval time.Duration `env:"ENDPOINT_ACCOUNTING_POLL_INTERVAL" envDefault:"10s"`
+	// EndpointAccountingResolvePods is meant to enable resolving each reported endpoint's IP to
+	// a Kubernetes Pod name/namespace in userspace (via the same OVN logical-port cache used by
+	// enricher.OVNEnricher), when EnableEndpointAccounting is set. This would only add labels to
+	// the reported usage; it wouldn't change the MAC/IP granularity the kernel map is keyed by.
+	// Same caveat as EnableEndpointAccounting: unused until that tracer is wired in.
+	EndpointAccountingResolvePods bool `env:"ENDPOINT_ACCOUNTING_RESOLVE_PODS" envDefault:"false"`
+	// OTLPEndpoint is the OTLP/HTTP collector URL, when Export is "otlp", "otlp+grpc" or
+	// "otlp+http" (the last two are accepted as synonyms of "otlp": this agent only implements
+	// the OTLP/HTTP transport). E.g. "http://collector:4318/v1/logs".
+	OTLPEndpoint string `env:"OTLP_ENDPOINT"`
+	// OTLPHeaders is a comma-separated list of "k=v" pairs sent as extra HTTP headers on every
+	// OTLP request, e.g. for collector authentication.
+	OTLPHeaders string `env:"OTLP_HEADERS"`
+	// OTLPCompression is the compression applied to the OTLP request body. Accepted values are
+	// "none" (default) or "gzip".
+	OTLPCompression string `env:"OTLP_COMPRESSION" envDefault:"none"`
+	// OTLPTimeout bounds how long the agent waits for the collector to accept a batch.
+	OTLPTimeout time.Duration `env:"OTLP_TIMEOUT" envDefault:"10s"`
+	// OTLPEncoding selects how flows are mapped onto OTLP signals. Accepted values are "logs"
+	// (default, one log record per flow with net.* attributes) or "metrics" (aggregate
+	// netobserv.flow.bytes/netobserv.flow.packets Sum data points per export batch).
+	OTLPEncoding string `env:"OTLP_ENCODING" envDefault:"logs"`
+	// OTLPEnableTLS set true to enable TLS when dialing OTLPEndpoint.
+	OTLPEnableTLS bool `env:"OTLP_TLS_ENABLE" envDefault:"false"`
+	// OTLPTLSInsecureSkipVerify skips server certificate verification in TLS connections.
+	OTLPTLSInsecureSkipVerify bool `env:"OTLP_TLS_INSECURE_SKIP_VERIFY" envDefault:"false"`
+	// OTLPTLSCACertPath is the path to the OTLP collector's certificate for TLS connections.
+	OTLPTLSCACertPath string `env:"OTLP_TLS_CA_CERT_PATH"`
+	// OTLPTLSUserCertPath is the path to the user (client) certificate for mTLS connections.
+	OTLPTLSUserCertPath string `env:"OTLP_TLS_USER_CERT_PATH"`
+	// OTLPTLSUserKeyPath is the path to the user (client) private key for mTLS connections.
+	OTLPTLSUserKeyPath string `env:"OTLP_TLS_USER_KEY_PATH"`
+	// ConfigOverlayPath, if set, points to a mounted config file (see agent.ConfigOverlay) that
+	// is polled for changes and hot-reloaded into the running agent without a restart. Empty
+	// (default) disables hot reloading.
+	ConfigOverlayPath string `env:"CONFIG_OVERLAY_PATH"`
+	// ConfigOverlayPollInterval is how often ConfigOverlayPath is checked for changes.
+	ConfigOverlayPollInterval time.Duration `env:"CONFIG_OVERLAY_POLL_INTERVAL" envDefault:"5s"`
 }
@@ -0,0 +1,261 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/ifaces"
+	"github.com/sirupsen/logrus"
+)
+
+var rlog = logrus.WithField("component", "agent.ConfigWatcher")
+
+// ConfigOverlay is the reloadable subset of Config: a file (JSON, kept as the lowest common
+// denominator of the "YAML/JSON superset" this subsystem targets, since this agent doesn't
+// vendor a YAML decoder) mounted over the running agent that, when changed, is re-applied via
+// Flows.Reload without a process restart.
+//
+// Every field is a pointer so that an overlay file only needs to mention the settings it wants
+// to override: a nil field leaves the corresponding Config field untouched. Export is accepted
+// purely so Reload can detect and reject an attempt to change it, with a clear error, rather than
+// silently ignoring an unsupported request.
+type ConfigOverlay struct {
+	Export             *string        `json:"export,omitempty"`
+	Sampling           *int           `json:"sampling,omitempty"`
+	Interfaces         *[]string      `json:"interfaces,omitempty"`
+	ExcludeInterfaces  *[]string      `json:"excludeInterfaces,omitempty"`
+	InterfaceIPs       *[]string      `json:"interfaceIPs,omitempty"`
+	Direction          *string        `json:"direction,omitempty"`
+	CacheMaxFlows      *int           `json:"cacheMaxFlows,omitempty"`
+	CacheActiveTimeout *time.Duration `json:"cacheActiveTimeout,omitempty"`
+	Deduper            *string        `json:"deduper,omitempty"`
+	DeduperFCExpiry    *time.Duration `json:"deduperFCExpiry,omitempty"`
+	DeduperJustMark    *bool          `json:"deduperJustMark,omitempty"`
+	DeduperMerge       *bool          `json:"deduperMerge,omitempty"`
+	PCAFilters         *string        `json:"pcaFilters,omitempty"`
+	LogLevel           *string        `json:"logLevel,omitempty"`
+	EnablePktDrops     *bool          `json:"enablePktDrops,omitempty"`
+	EnableDNSTracking  *bool          `json:"enableDNSTracking,omitempty"`
+	EnableRTT          *bool          `json:"enableRTT,omitempty"`
+}
+
+// ParseConfigOverlay decodes a ConfigOverlay from a mounted config file's contents.
+func ParseConfigOverlay(data []byte) (*ConfigOverlay, error) {
+	var overlay ConfigOverlay
+	if err := json.Unmarshal(data, &overlay); err != nil {
+		return nil, fmt.Errorf("decoding config overlay: %w", err)
+	}
+	return &overlay, nil
+}
+
+// ConfigWatcher polls a mounted config file for changes and feeds the decoded ConfigOverlay to
+// an apply function (ordinarily Flows.Reload).
+//
+// It polls the file's modification time instead of using fsnotify (which this agent doesn't
+// vendor): ConfigMap-mounted files are themselves updated through an atomic symlink swap by the
+// kubelet, so a short poll period is indistinguishable in practice from an inotify-driven watch,
+// at the cost of up to one PollInterval of latency.
+type ConfigWatcher struct {
+	Path         string
+	PollInterval time.Duration
+
+	lastModTime time.Time
+}
+
+// NewConfigWatcher builds a ConfigWatcher for path, polling every pollInterval (5s if <= 0).
+func NewConfigWatcher(path string, pollInterval time.Duration) *ConfigWatcher {
+	if pollInterval <= 0 {
+		pollInterval = 5 * time.Second
+	}
+	return &ConfigWatcher{Path: path, PollInterval: pollInterval}
+}
+
+// Watch polls w.Path until ctx is canceled, invoking apply with the freshly parsed overlay every
+// time the file's content changes. A read, parse or apply failure is logged and retried at the
+// next tick rather than stopping the watch, so a single bad edit doesn't wedge reloading.
+func (w *ConfigWatcher) Watch(ctx context.Context, apply func(*ConfigOverlay) error) {
+	ticker := time.NewTicker(w.PollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			w.poll(apply)
+		}
+	}
+}
+
+func (w *ConfigWatcher) poll(apply func(*ConfigOverlay) error) {
+	info, err := os.Stat(w.Path)
+	if err != nil {
+		rlog.WithError(err).Warn("can't stat config overlay file")
+		return
+	}
+	if !info.ModTime().After(w.lastModTime) {
+		return
+	}
+	w.lastModTime = info.ModTime()
+
+	data, err := os.ReadFile(w.Path)
+	if err != nil {
+		rlog.WithError(err).Warn("can't read config overlay file")
+		return
+	}
+	overlay, err := ParseConfigOverlay(data)
+	if err != nil {
+		rlog.WithError(err).Warn("can't parse config overlay file")
+		return
+	}
+	if err := apply(overlay); err != nil {
+		rlog.WithError(err).Warn("can't apply config overlay")
+	}
+}
+
+// Reload atomically applies the fields set in overlay onto the running agent: it diffs them
+// against the current Config, rejects a change to Export (the exporter protocol can't be swapped
+// without restarting the exporter's underlying connection/writer), rebuilds the interface filter
+// when any selector changed, and detaches any tracer whose interface no longer matches it. A
+// Sampling change additionally re-registers every still-matching tracer, since the sampling rate
+// is baked into a tracer at Register time.
+//
+// CacheMaxFlows/CacheActiveTimeout are applied to Config and take effect for any interface's
+// Accounter built afterwards (a new one on onInterfaceAdded, or an existing one's replacement on
+// a Sampling-triggered reattachAllTracers), but can't be pushed into an already-running Accounter
+// in place, so an operator changing either of those without also changing Sampling still needs a
+// restart (or the matching interfaces to churn) to affect already-accumulating flows.
+//
+// Direction, Deduper, DeduperFCExpiry, DeduperJustMark, DeduperMerge, PCAFilters,
+// EnablePktDrops, EnableDNSTracking and EnableRTT are only recorded in Config: nothing in the
+// running agent reads them, so changing them has no effect on tracing, deduplication or export.
+func (f *Flows) Reload(overlay *ConfigOverlay) error {
+	if overlay.Export != nil && *overlay.Export != f.cfg.Export {
+		return fmt.Errorf("can't change Export protocol (%q -> %q) without restarting the agent", f.cfg.Export, *overlay.Export)
+	}
+
+	f.cfgMu.Lock()
+	reattachSampling := overlay.Sampling != nil && *overlay.Sampling != f.cfg.Sampling
+	rebuildFilter := overlay.Interfaces != nil || overlay.ExcludeInterfaces != nil || overlay.InterfaceIPs != nil
+
+	if overlay.Sampling != nil {
+		f.cfg.Sampling = *overlay.Sampling
+	}
+	if overlay.Interfaces != nil {
+		f.cfg.Interfaces = *overlay.Interfaces
+	}
+	if overlay.ExcludeInterfaces != nil {
+		f.cfg.ExcludeInterfaces = *overlay.ExcludeInterfaces
+	}
+	if overlay.InterfaceIPs != nil {
+		f.cfg.InterfaceIPs = *overlay.InterfaceIPs
+	}
+	if overlay.Direction != nil {
+		f.cfg.Direction = *overlay.Direction
+	}
+	if overlay.CacheMaxFlows != nil {
+		f.cfg.CacheMaxFlows = *overlay.CacheMaxFlows
+	}
+	if overlay.CacheActiveTimeout != nil {
+		f.cfg.CacheActiveTimeout = *overlay.CacheActiveTimeout
+	}
+	if overlay.Deduper != nil {
+		f.cfg.Deduper = *overlay.Deduper
+	}
+	if overlay.DeduperFCExpiry != nil {
+		f.cfg.DeduperFCExpiry = *overlay.DeduperFCExpiry
+	}
+	if overlay.DeduperJustMark != nil {
+		f.cfg.DeduperJustMark = *overlay.DeduperJustMark
+	}
+	if overlay.DeduperMerge != nil {
+		f.cfg.DeduperMerge = *overlay.DeduperMerge
+	}
+	if overlay.PCAFilters != nil {
+		f.cfg.PCAFilters = *overlay.PCAFilters
+	}
+	if overlay.LogLevel != nil {
+		f.cfg.LogLevel = *overlay.LogLevel
+		if level, err := logrus.ParseLevel(*overlay.LogLevel); err == nil {
+			logrus.SetLevel(level)
+		} else {
+			rlog.WithError(err).Warn("can't apply reloaded LogLevel")
+		}
+	}
+	if overlay.EnablePktDrops != nil {
+		f.cfg.EnablePktDrops = *overlay.EnablePktDrops
+	}
+	if overlay.EnableDNSTracking != nil {
+		f.cfg.EnableDNSTracking = *overlay.EnableDNSTracking
+	}
+	if overlay.EnableRTT != nil {
+		f.cfg.EnableRTT = *overlay.EnableRTT
+	}
+
+	if rebuildFilter {
+		filter, err := initInterfaceFilter(f.cfg.Interfaces, f.cfg.ExcludeInterfaces)
+		if err != nil {
+			f.cfgMu.Unlock()
+			return fmt.Errorf("rebuilding interface filter: %w", err)
+		}
+		f.filter = filter
+	}
+	f.cfgMu.Unlock()
+
+	if rebuildFilter {
+		f.detachUnmatchedTracers()
+	}
+	if reattachSampling {
+		f.reattachAllTracers()
+	}
+	alog.Info("applied configuration reload")
+	return nil
+}
+
+// detachUnmatchedTracers drops any registered tracer whose interface no longer passes the
+// (just-rebuilt) filter, the same way onInterfaceDeleted does for an interface that disappeared:
+// draining its accounter before letting it go, so the reload doesn't lose that interface's
+// in-flight flows either.
+func (f *Flows) detachUnmatchedTracers() {
+	f.cfgMu.RLock()
+	filter := f.filter
+	f.cfgMu.RUnlock()
+
+	f.trMutex.Lock()
+	defer f.trMutex.Unlock()
+	for name, ft := range f.tracers {
+		if !filter.Allowed(name) {
+			alog.WithField("name", name).Info("interface no longer matches reloaded filters. Removing flow tracer")
+			f.detachTracer(ft)
+			delete(f.tracers, name)
+		}
+	}
+}
+
+// reattachAllTracers unregisters and re-registers every currently attached tracer, so a new
+// Sampling value (baked into a tracer at creation time) takes effect without waiting for each
+// interface to be removed and re-added. Each old sub-pipeline is drained, the same way
+// onInterfaceDeleted drains one, before its replacement is started, so the resampling doesn't
+// lose whatever flows the old Accounter had pending.
+func (f *Flows) reattachAllTracers() {
+	if f.runCtx == nil {
+		return
+	}
+	f.trMutex.Lock()
+	names := make([]ifaces.Name, 0, len(f.tracers))
+	for name, ft := range f.tracers {
+		f.detachTracer(ft)
+		if err := ft.tracer.Unregister(); err != nil {
+			alog.WithField("name", name).WithError(err).Warn("can't unregister flow tracer for reload")
+		}
+		names = append(names, name)
+	}
+	f.tracers = map[ifaces.Name]cancellableTracer{}
+	f.trMutex.Unlock()
+
+	for _, name := range names {
+		f.onInterfaceAdded(f.runCtx, name)
+	}
+}
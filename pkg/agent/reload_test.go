@@ -0,0 +1,55 @@
+package agent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/ifaces"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConfigOverlay(t *testing.T) {
+	overlay, err := ParseConfigOverlay([]byte(`{"sampling":50,"logLevel":"debug","cacheActiveTimeout":"10s"}`))
+	require.NoError(t, err)
+	require.NotNil(t, overlay.Sampling)
+	assert.Equal(t, 50, *overlay.Sampling)
+	require.NotNil(t, overlay.LogLevel)
+	assert.Equal(t, "debug", *overlay.LogLevel)
+	require.NotNil(t, overlay.CacheActiveTimeout)
+	assert.Equal(t, 10*time.Second, *overlay.CacheActiveTimeout)
+	assert.Nil(t, overlay.Direction)
+}
+
+func TestParseConfigOverlay_Invalid(t *testing.T) {
+	_, err := ParseConfigOverlay([]byte(`not json`))
+	assert.Error(t, err)
+}
+
+func TestFlowsReload_RejectsExportChange(t *testing.T) {
+	f := &Flows{cfg: &Config{Export: "grpc"}, tracers: map[ifaces.Name]cancellableTracer{}}
+	newExport := "kafka"
+	err := f.Reload(&ConfigOverlay{Export: &newExport})
+	assert.Error(t, err)
+	assert.Equal(t, "grpc", f.cfg.Export)
+}
+
+func TestFlowsReload_AppliesSamplingAndCacheFields(t *testing.T) {
+	f := &Flows{cfg: &Config{Export: "grpc", Sampling: 1, CacheMaxFlows: 100}, tracers: map[ifaces.Name]cancellableTracer{}}
+	sampling := 64
+	maxFlows := 500
+	logLevel := "warn"
+	err := f.Reload(&ConfigOverlay{Sampling: &sampling, CacheMaxFlows: &maxFlows, LogLevel: &logLevel})
+	require.NoError(t, err)
+	assert.Equal(t, 64, f.cfg.Sampling)
+	assert.Equal(t, 500, f.cfg.CacheMaxFlows)
+	assert.Equal(t, "warn", f.cfg.LogLevel)
+}
+
+func TestFlowsReload_NoOverlayFieldsLeavesConfigUnchanged(t *testing.T) {
+	cfg := &Config{Export: "grpc", Sampling: 1}
+	f := &Flows{cfg: cfg, tracers: map[ifaces.Name]cancellableTracer{}}
+	err := f.Reload(&ConfigOverlay{})
+	require.NoError(t, err)
+	assert.Equal(t, 1, cfg.Sampling)
+}
@@ -0,0 +1,147 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/sirupsen/logrus"
+)
+
+var sslog = logrus.WithField("component", "agent.SnapshotScheduler")
+
+const (
+	ConcurrencyAllow   = "Allow"
+	ConcurrencyForbid  = "Forbid"
+	ConcurrencyReplace = "Replace"
+)
+
+// SnapshotScheduleConfig reuses the batch/v1 CronJob field semantics (schedule expression,
+// IANA time zone, concurrency policy) so that operators already familiar with CronJob don't
+// have to learn a second scheduling syntax for in-process scheduled captures.
+type SnapshotScheduleConfig struct {
+	// Schedule is a standard 5-field cron expression.
+	Schedule string
+	// TimeZone is an IANA time zone name (e.g. "America/New_York"). Empty means UTC.
+	TimeZone string
+	// ConcurrencyPolicy is one of ConcurrencyAllow (default), ConcurrencyForbid or
+	// ConcurrencyReplace, applied when a previous capture window is still exporting when the
+	// next firing is due.
+	ConcurrencyPolicy string
+	// StartingDeadline bounds how late a missed firing may still start. Zero means no bound.
+	StartingDeadline time.Duration
+	// Window is the duration of each capture window.
+	Window time.Duration
+}
+
+// WindowFunc captures one scheduled window, tagging every exported flow with snapshotID, and
+// returns once the window's flows (and its end-of-window marker) have been handed to the
+// exporter.
+type WindowFunc func(ctx context.Context, snapshotID string)
+
+// SnapshotScheduler fires WindowFunc at each occurrence of a CronJob-shaped schedule, applying
+// the same Forbid/Replace/Allow semantics as the Kubernetes CronJob controller when a previous
+// window is still running.
+//
+// Nothing in pkg/agent constructs a SnapshotScheduler yet: Flows.Run never checks
+// Config.SnapshotSchedule, so those config fields are parsed but unread. Wiring this in also
+// needs a SnapshotID field threaded through flow.Record into the exporter schemas, not just a
+// call to NewSnapshotScheduler, since WindowFunc is meant to tag every flow it exports with the
+// firing's snapshotID.
+type SnapshotScheduler struct {
+	cfg      SnapshotScheduleConfig
+	schedule cron.Schedule
+	loc      *time.Location
+	window   WindowFunc
+
+	mu      sync.Mutex
+	running bool
+	cancel  context.CancelFunc
+	gen     uint64
+}
+
+// NewSnapshotScheduler validates cfg.Schedule and cfg.TimeZone and returns a scheduler ready to
+// be started.
+func NewSnapshotScheduler(cfg SnapshotScheduleConfig, window WindowFunc) (*SnapshotScheduler, error) {
+	if cfg.ConcurrencyPolicy == "" {
+		cfg.ConcurrencyPolicy = ConcurrencyAllow
+	}
+	loc := time.UTC
+	if cfg.TimeZone != "" {
+		var err error
+		loc, err = time.LoadLocation(cfg.TimeZone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeZone %q: %w", cfg.TimeZone, err)
+		}
+	}
+	parser := cron.NewParser(cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow)
+	schedule, err := parser.Parse(cfg.Schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schedule %q: %w", cfg.Schedule, err)
+	}
+	return &SnapshotScheduler{
+		cfg:      cfg,
+		schedule: schedule,
+		loc:      loc,
+		window:   window,
+	}, nil
+}
+
+// Run blocks, firing capture windows according to the schedule, until ctx is canceled.
+func (s *SnapshotScheduler) Run(ctx context.Context) {
+	now := time.Now().In(s.loc)
+	next := s.schedule.Next(now)
+	for {
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case fireTime := <-timer.C:
+			if s.cfg.StartingDeadline > 0 && time.Since(next) > s.cfg.StartingDeadline {
+				sslog.WithField("missedBy", time.Since(next)).
+					Warn("skipping firing: past startingDeadlineSeconds")
+			} else {
+				s.fire(ctx)
+			}
+			next = s.schedule.Next(fireTime)
+		}
+	}
+}
+
+func (s *SnapshotScheduler) fire(ctx context.Context) {
+	s.mu.Lock()
+	if s.running {
+		switch s.cfg.ConcurrencyPolicy {
+		case ConcurrencyForbid:
+			s.mu.Unlock()
+			sslog.Debug("previous snapshot still running. Forbid: skipping this firing")
+			return
+		case ConcurrencyReplace:
+			sslog.Debug("previous snapshot still running. Replace: canceling it")
+			s.cancel()
+		}
+	}
+	winCtx, cancel := context.WithTimeout(ctx, s.cfg.Window)
+	s.cancel = cancel
+	s.running = true
+	s.gen++
+	myGen := s.gen
+	s.mu.Unlock()
+
+	snapshotID := time.Now().UTC().Format(time.RFC3339Nano)
+	go func() {
+		defer cancel()
+		s.window(winCtx, snapshotID)
+		s.mu.Lock()
+		// Only clear running if no later firing has replaced this window in the meantime:
+		// under Replace, a canceled window's goroutine can wake up and return after the next
+		// window has already started, and must not stomp on that newer window's state.
+		if s.gen == myGen {
+			s.running = false
+		}
+		s.mu.Unlock()
+	}()
+}
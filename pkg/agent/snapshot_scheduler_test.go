@@ -0,0 +1,70 @@
+package agent
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSnapshotScheduler_InvalidSchedule(t *testing.T) {
+	_, err := NewSnapshotScheduler(SnapshotScheduleConfig{Schedule: "not a cron expression"}, nil)
+	assert.Error(t, err)
+}
+
+func TestSnapshotScheduler_Fire(t *testing.T) {
+	var fired int32
+	s, err := NewSnapshotScheduler(SnapshotScheduleConfig{
+		Schedule:          "* * * * *",
+		ConcurrencyPolicy: ConcurrencyForbid,
+		Window:            50 * time.Millisecond,
+	}, func(ctx context.Context, snapshotID string) {
+		atomic.AddInt32(&fired, 1)
+		require.NotEmpty(t, snapshotID)
+		<-ctx.Done()
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.fire(ctx)
+	// a second firing while the first is still running should be skipped under Forbid
+	s.fire(ctx)
+	time.Sleep(100 * time.Millisecond)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&fired))
+}
+
+func TestSnapshotScheduler_Replace_OldWindowDoesNotClobberRunning(t *testing.T) {
+	started := make(chan struct{}, 2)
+	s, err := NewSnapshotScheduler(SnapshotScheduleConfig{
+		Schedule:          "* * * * *",
+		ConcurrencyPolicy: ConcurrencyReplace,
+		Window:            time.Second,
+	}, func(ctx context.Context, _ string) {
+		started <- struct{}{}
+		<-ctx.Done()
+		// simulate the replaced window's goroutine waking up and returning well after the
+		// replacing window has already started
+		time.Sleep(50 * time.Millisecond)
+	})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s.fire(ctx) // window A
+	<-started
+	s.fire(ctx) // Replace: cancels A, starts window B
+	<-started
+
+	// give A's goroutine time to wake from cancellation and return
+	time.Sleep(100 * time.Millisecond)
+
+	s.mu.Lock()
+	running := s.running
+	s.mu.Unlock()
+	assert.True(t, running, "window A's late return must not clear running for window B")
+}
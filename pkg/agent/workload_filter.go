@@ -0,0 +1,125 @@
+package agent
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/ifaces"
+	"github.com/sirupsen/logrus"
+)
+
+var wlog = logrus.WithField("component", "agent.WorkloadFilter")
+
+const (
+	// AnnotationCapture lets a Pod or Namespace opt in/out of flow capture, or restrict it to
+	// a direction. Accepted values: "ingress", "egress", "both" or "off".
+	AnnotationCapture = "flows.netobserv.io/capture"
+	// AnnotationSampling overrides the global Sampling rate for the annotated workload.
+	AnnotationSampling = "flows.netobserv.io/sampling"
+	// AnnotationL7Visibility lets a Pod or Namespace opt in/out of the Layer-7 (HTTP/gRPC/
+	// TLS-SNI) parsing overhead independently of the global EnableL7Tracking default, for
+	// operators who only need it on a handful of latency-sensitive workloads. Accepted
+	// values: "true" or "false".
+	AnnotationL7Visibility = "flows.netobserv.io/l7-visibility"
+
+	captureOff = "off"
+)
+
+// WorkloadCaptureSetting is the desired capture configuration for a single interface, resolved
+// from the annotations of the Pod that owns it.
+type WorkloadCaptureSetting struct {
+	Direction    string
+	Sampling     uint32
+	L7Visibility bool
+}
+
+// workloadFilter maintains a reconciled, per-interface desired state derived from Pod/Namespace
+// annotations, so that onInterfaceAdded/onInterfaceDeleted can attach/detach eBPF hooks and
+// sampling accordingly without re-querying the API server on every flow.
+//
+// This type only implements the annotation-resolution and reconciliation logic. Wiring it to a
+// live Pod/Namespace informer (to resolve a Pod's host-side veth and to watch annotation
+// changes) is left for a follow-up change, since it requires a Kubernetes API client that this
+// agent does not currently depend on.
+type workloadFilter struct {
+	settings map[ifaces.Name]WorkloadCaptureSetting
+}
+
+func newWorkloadFilter() *workloadFilter {
+	return &workloadFilter{settings: map[ifaces.Name]WorkloadCaptureSetting{}}
+}
+
+// Reconcile updates the desired capture state for the given interface according to the Pod
+// and Namespace annotations (Pod annotations take precedence over Namespace ones). It returns
+// the resolved setting and whether the interface should be captured at all.
+func (w *workloadFilter) Reconcile(iface ifaces.Name, podAnnotations, nsAnnotations map[string]string, defaultSampling uint32, defaultL7Visibility bool) (WorkloadCaptureSetting, bool) {
+	setting := WorkloadCaptureSetting{Direction: DirectionBoth, Sampling: defaultSampling, L7Visibility: defaultL7Visibility}
+
+	direction, ok := parseCaptureAnnotation(podAnnotations[AnnotationCapture])
+	if !ok {
+		direction, ok = parseCaptureAnnotation(nsAnnotations[AnnotationCapture])
+	}
+	if ok {
+		if direction == captureOff {
+			delete(w.settings, iface)
+			wlog.WithField("interface", iface).Debug("capture disabled by annotation")
+			return setting, false
+		}
+		setting.Direction = direction
+	}
+
+	if sampling, ok := parseSamplingAnnotation(podAnnotations[AnnotationSampling]); ok {
+		setting.Sampling = sampling
+	} else if sampling, ok := parseSamplingAnnotation(nsAnnotations[AnnotationSampling]); ok {
+		setting.Sampling = sampling
+	}
+
+	if visible, ok := parseBoolAnnotation(podAnnotations[AnnotationL7Visibility]); ok {
+		setting.L7Visibility = visible
+	} else if visible, ok := parseBoolAnnotation(nsAnnotations[AnnotationL7Visibility]); ok {
+		setting.L7Visibility = visible
+	}
+
+	w.settings[iface] = setting
+	return setting, true
+}
+
+// Forget removes any reconciled state for the given interface, e.g. when it is deleted.
+func (w *workloadFilter) Forget(iface ifaces.Name) {
+	delete(w.settings, iface)
+}
+
+func parseCaptureAnnotation(value string) (string, bool) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case DirectionIngress, DirectionEgress, DirectionBoth, captureOff:
+		return strings.ToLower(strings.TrimSpace(value)), true
+	default:
+		return "", false
+	}
+}
+
+func parseBoolAnnotation(value string) (bool, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return false, false
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		wlog.WithField("value", value).WithError(err).Debug("invalid boolean annotation. Ignoring")
+		return false, false
+	}
+	return b, true
+}
+
+func parseSamplingAnnotation(value string) (uint32, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(value, 10, 32)
+	if err != nil {
+		wlog.WithField("value", value).WithError(err).Debug("invalid sampling annotation. Ignoring")
+		return 0, false
+	}
+	return uint32(n), true
+}
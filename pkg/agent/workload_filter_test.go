@@ -0,0 +1,50 @@
+package agent
+
+import (
+	"testing"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/ifaces"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkloadFilter_Reconcile(t *testing.T) {
+	w := newWorkloadFilter()
+
+	setting, capture := w.Reconcile("veth0",
+		map[string]string{AnnotationCapture: "ingress", AnnotationSampling: "50"},
+		nil, 100, false)
+	assert.True(t, capture)
+	assert.Equal(t, DirectionIngress, setting.Direction)
+	assert.EqualValues(t, 50, setting.Sampling)
+	assert.False(t, setting.L7Visibility)
+
+	// namespace annotation is used as a fallback when the pod doesn't define one
+	setting, capture = w.Reconcile("veth1", nil,
+		map[string]string{AnnotationCapture: "egress"}, 100, false)
+	assert.True(t, capture)
+	assert.Equal(t, DirectionEgress, setting.Direction)
+	assert.EqualValues(t, 100, setting.Sampling)
+
+	// "off" disables capture for that interface
+	_, capture = w.Reconcile("veth2", map[string]string{AnnotationCapture: "off"}, nil, 100, false)
+	assert.False(t, capture)
+
+	w.Forget("veth0")
+	assert.NotContains(t, w.settings, ifaces.Name("veth0"))
+}
+
+func TestWorkloadFilter_Reconcile_L7Visibility(t *testing.T) {
+	w := newWorkloadFilter()
+
+	// pod annotation overrides the global default
+	setting, _ := w.Reconcile("veth0", map[string]string{AnnotationL7Visibility: "true"}, nil, 100, false)
+	assert.True(t, setting.L7Visibility)
+
+	// namespace annotation is used as a fallback when the pod doesn't define one
+	setting, _ = w.Reconcile("veth1", nil, map[string]string{AnnotationL7Visibility: "false"}, 100, true)
+	assert.False(t, setting.L7Visibility)
+
+	// no annotation: falls back to the global default
+	setting, _ = w.Reconcile("veth2", nil, nil, 100, true)
+	assert.True(t, setting.L7Visibility)
+}
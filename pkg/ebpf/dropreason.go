@@ -0,0 +1,49 @@
+package ebpf
+
+// dropReasonsMap is the name of the BPF_MAP_TYPE_HASH map, keyed by the same flow_id as
+// aggregated_flows, that an accompanying classifier program would populate with DropReason
+// whenever it observes a flow being denied rather than forwarded.
+const dropReasonsMap = "drop_reasons"
+
+// Drop/deny reason codes a DropReason can carry. PolicyDeny is the only one that also sets
+// PolicyRef, the remaining ones are inferred straight from the kernel verdict/error that
+// killed the flow.
+const (
+	// DropReasonNetfilter means a netfilter hook returned NF_DROP for the packet.
+	DropReasonNetfilter uint8 = iota + 1
+	// DropReasonTCPReset means the connection was torn down by a TCP RST rather than the
+	// usual FIN handshake.
+	DropReasonTCPReset
+	// DropReasonICMPUnreachable means an ICMP (or ICMPv6) "destination unreachable" was
+	// observed for the flow.
+	DropReasonICMPUnreachable
+	// DropReasonSocketError means the flow ended on a kernel socket error (e.g. ECONNREFUSED)
+	// rather than any of the above.
+	DropReasonSocketError
+	// DropReasonPolicyDeny means a tail-called classifier program denied the flow against a
+	// user-supplied policy; PolicyRef identifies which one.
+	DropReasonPolicyDeny
+)
+
+// DropReason is the application-level metadata FlowFetcher's classifier hook would attach to a
+// denied flow, analogous to L7Record for forwarded ones.
+type DropReason struct {
+	// Reason is one of the DropReason* constants above.
+	Reason uint8
+	// PolicyRef identifies the policy that caused the drop (e.g. a NetworkPolicy name), set
+	// only when Reason is DropReasonPolicyDeny.
+	PolicyRef string
+}
+
+// DropReasons reads and clears the drop_reasons map, returning the drop/deny metadata
+// captured for each flow seen since the last call, for merging into the corresponding flow
+// Record by the caller.
+//
+// This build doesn't ship the generated BPF program/map backing it: classifying NF_DROP
+// verdicts, TCP RSTs, ICMP unreachables and tail-called policy denials into a flow_id-keyed map
+// requires bpf/flows.c support that isn't part of this source tree. Like DrainShadowMap, it
+// always returns an empty result rather than silently pretending to have classified anything,
+// since the caller already treats drop reason metadata as optional enrichment.
+func (m *FlowFetcher) DropReasons() map[BpfFlowId]*DropReason {
+	return nil
+}
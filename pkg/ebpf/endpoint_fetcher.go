@@ -0,0 +1,45 @@
+package ebpf
+
+import "fmt"
+
+// endpointBytesMap is the name of the BPF_MAP_TYPE_LRU_HASH sidecar map that EndpointFetcher
+// reads from.
+const endpointBytesMap = "endpoint_bytes"
+
+// EndpointKey identifies a monitored endpoint by its link-layer and network-layer address, the
+// granularity at which the "endpoint_bytes" map accumulates bandwidth counters.
+type EndpointKey struct {
+	Mac [6]uint8
+	IP  [16]uint8
+}
+
+// EndpointBytes holds the rolling bytes-in/bytes-out counters the kernel accumulates for an
+// EndpointKey between two userspace reads.
+type EndpointBytes struct {
+	BytesIn  uint64
+	BytesOut uint64
+}
+
+// EndpointFetcher reads the "endpoint_bytes" BPF_MAP_TYPE_LRU_HASH map: a lightweight
+// alternative to the full 5-tuple aggregated_flows map that lets the kernel age out endpoints
+// under memory pressure, instead of relying on userspace scanning like FlowFetcher's stale-entry
+// eviction does.
+//
+// This build doesn't ship the generated BPF program/map pair backing it: it requires an
+// "endpoint_bytes" BPF_MAP_TYPE_LRU_HASH map populated from bpf/flows.c, which isn't part of
+// this source tree. NewEndpointFetcher always fails with a clear error rather than silently
+// doing nothing.
+type EndpointFetcher struct{}
+
+// NewEndpointFetcher builds an EndpointFetcher bound to the "endpoint_bytes" map of an already
+// loaded FlowFetcher's BpfObjects.
+func NewEndpointFetcher(_ *BpfObjects) (*EndpointFetcher, error) {
+	return nil, fmt.Errorf("endpoint bandwidth accounting: no generated %q BPF_MAP_TYPE_LRU_HASH map available in this build", endpointBytesMap)
+}
+
+// LookupAndDeleteMap drains the current contents of the "endpoint_bytes" map, returning the
+// accumulated counters for every endpoint seen since the last call.
+func (f *EndpointFetcher) LookupAndDeleteMap() map[EndpointKey]EndpointBytes { return nil }
+
+// Close releases the map handle.
+func (f *EndpointFetcher) Close() error { return nil }
@@ -0,0 +1,38 @@
+package ebpf
+
+// l7RecordsMap is the name of the BPF_MAP_TYPE_HASH map, keyed by the same flow_id as
+// aggregated_flows, that the egress/ingress TC programs would populate with L7Record when
+// EnableL7Tracking parses a recognizable HTTP/1.x or HTTP/2 request/response out of a flow's
+// first packets. No program in this build populates it (see L7Records).
+const l7RecordsMap = "l7_records"
+
+// L7Record is the application-level metadata FlowFetcher's TC hooks extract for a flow, in
+// addition to the existing DNS tracking: an HTTP/1.x request/response line, or the :method/
+// :path pseudo-headers of an HTTP/2 HEADERS frame when they're static-table-indexed (Huffman-
+// coded or dynamic-table-indexed header values are left blank rather than guessed at).
+type L7Record struct {
+	// Proto is "HTTP/1.0", "HTTP/1.1" or "HTTP/2", depending on which parser matched.
+	Proto string
+	// Method is the HTTP method ("GET", "POST", ...), or the gRPC method name when a
+	// decoded HTTP/2 :path pseudo-header looks like a gRPC "/package.Service/Method" path.
+	Method string
+	// Path is the first path segment of the request (e.g. "/v1" out of "/v1/users/42"),
+	// truncated this way to keep map values small and avoid leaking high-cardinality IDs.
+	Path string
+	// StatusCode is the HTTP response status code, 0 if this record is for a request.
+	StatusCode uint16
+}
+
+// L7Records reads and clears the l7_records map, returning the application-level metadata
+// captured for each flow seen since the last call, for merging into the corresponding flow
+// Record by the caller.
+//
+// This build doesn't ship the generated BPF program/map backing it: parsing HTTP/1.x request
+// lines and bounded HTTP/2 HEADERS frames inside the egress/ingress TC programs requires
+// bpf/flows.c support (and a verifier-bounded #pragma unroll loop over the first 64 payload
+// bytes) that isn't part of this source tree. Like DrainShadowMap, it always returns an empty
+// result rather than silently pretending to have parsed anything, since the caller already
+// treats L7 metadata as optional enrichment.
+func (m *FlowFetcher) L7Records() map[BpfFlowId]*L7Record {
+	return nil
+}
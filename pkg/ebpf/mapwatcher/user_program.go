@@ -0,0 +1,61 @@
+package mapwatcher
+
+import (
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/metrics"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/perf"
+	"github.com/cilium/ebpf/ringbuf"
+)
+
+// perfReaderPerCPUBufferSize is the per-CPU perf ring buffer size requested for a user-provided
+// BPF_MAP_TYPE_PERF_EVENT_ARRAY map, matching os.Getpagesize() rounded up to a conservative
+// default rather than probing the page size, since WatcherForMap has no os-specific context.
+const perfReaderPerCPUBufferSize = 4096
+
+// LoadUserProgram parses a compiled BPF object (e.g. a user-supplied .o file) into a
+// CollectionSpec, without loading it into the kernel yet, so its program and map specs can be
+// inspected before WatcherForMap decides how to attach to it.
+func LoadUserProgram(r io.Reader) (*ebpf.CollectionSpec, error) {
+	spec, err := ebpf.LoadCollectionSpecFromReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("parsing user-provided BPF object: %w", err)
+	}
+	return spec, nil
+}
+
+// WatcherForMap takes coll (already loaded into the kernel, e.g. via ebpf.NewCollection) and
+// returns a Watcher for its map named mapName, picking the polling, ring buffer or perf-event
+// source that matches the map's type. pollInterval is only used for hash-like maps.
+//
+// This is what lets an operator attach a program this agent doesn't ship (e.g. a custom
+// tracepoint probe) and have its map streamed into the same export pipeline as the built-in
+// tracers, without the agent needing a dedicated tracer type for it.
+func WatcherForMap(coll *ebpf.Collection, mapName string, decode Decoder, pollInterval time.Duration, met *metrics.Metrics) (*Watcher, error) {
+	m, ok := coll.Maps[mapName]
+	if !ok {
+		return nil, fmt.Errorf("user-provided BPF object has no map named %q", mapName)
+	}
+	switch m.Type() {
+	case ebpf.RingBuf:
+		rd, err := ringbuf.NewReader(m)
+		if err != nil {
+			return nil, fmt.Errorf("opening ring buffer reader for map %q: %w", mapName, err)
+		}
+		return NewRingBufWatcher(mapName, rd, decode, met), nil
+	case ebpf.PerfEventArray:
+		rd, err := perf.NewReader(m, perfReaderPerCPUBufferSize)
+		if err != nil {
+			return nil, fmt.Errorf("opening perf event reader for map %q: %w", mapName, err)
+		}
+		return NewPerfEventWatcher(mapName, rd, decode, met), nil
+	case ebpf.Hash, ebpf.LRUHash, ebpf.PerCPUHash, ebpf.LRUCPUHash:
+		return NewHashMapWatcher(mapName, m, decode, pollInterval, met), nil
+	default:
+		return nil, fmt.Errorf("map %q has unsupported type %s for mapwatcher", mapName, m.Type())
+	}
+}
@@ -0,0 +1,204 @@
+// Package mapwatcher provides a single, reusable way to stream decoded entries out of a BPF map,
+// regardless of whether that map is read by polling-and-deleting a hash map, by draining a
+// BPF_MAP_TYPE_RINGBUF ring buffer, or by consuming a BPF_MAP_TYPE_PERF_EVENT_ARRAY perf reader.
+// It exists so that loading a user-provided compiled BPF object at runtime doesn't also require
+// writing a bespoke tracer type for it: any map, described by its Decoder, can be streamed into
+// the same export pipeline the built-in tracers (flow.MapTracer, flow.EndpointTracer...) use.
+package mapwatcher
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/metrics"
+
+	"github.com/cilium/ebpf"
+	"github.com/cilium/ebpf/perf"
+	"github.com/cilium/ebpf/ringbuf"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+var wlog = logrus.WithField("component", "ebpf.mapwatcher.Watcher")
+
+// Decoder turns a raw BPF map key/value pair (or, for ring buffer and perf-event sources, a nil
+// key and the raw record bytes) into the decoded value that gets sent to Sink. Returning a nil
+// error with a nil value drops the entry silently (e.g. a record the decoder recognizes as
+// belonging to a stale eviction cycle).
+type Decoder func(key, value []byte) (any, error)
+
+// source abstracts the three ways a BPF map's contents reach userspace, so Watcher.Run can poll
+// any of them uniformly.
+type source interface {
+	// next blocks until the next batch of decoded values is available, or ctx is canceled.
+	next(ctx context.Context, decode Decoder) ([]any, error)
+	// close releases the underlying map/reader handle.
+	close() error
+}
+
+// Watcher drains a single BPF map source and publishes its decoded entries on Sink, recording
+// unified lookup-latency, eviction and error metrics regardless of the underlying source kind.
+type Watcher struct {
+	name          string
+	src           source
+	decode        Decoder
+	lookupLatency prometheus.Histogram
+	evictions     prometheus.Counter
+	errors        *metrics.ErrorCounter
+}
+
+func newWatcher(name string, src source, decode Decoder, m *metrics.Metrics) *Watcher {
+	return &Watcher{
+		name:          name,
+		src:           src,
+		decode:        decode,
+		lookupLatency: m.CreateMapWatcherLookupLatency(name),
+		evictions:     m.CreateMapWatcherEvictionCounter(name),
+		errors:        m.GetErrorsCounter(),
+	}
+}
+
+// NewHashMapWatcher builds a Watcher that polls m (a BPF_MAP_TYPE_HASH or BPF_MAP_TYPE_LRU_HASH
+// map) every pollInterval, looking up and deleting every entry currently in the map.
+func NewHashMapWatcher(name string, m *ebpf.Map, decode Decoder, pollInterval time.Duration, met *metrics.Metrics) *Watcher {
+	return newWatcher(name, &hashPollSource{m: m, pollInterval: pollInterval}, decode, met)
+}
+
+// NewRingBufWatcher builds a Watcher that blocks on rd.Read() for each new ring buffer record.
+func NewRingBufWatcher(name string, rd *ringbuf.Reader, decode Decoder, met *metrics.Metrics) *Watcher {
+	return newWatcher(name, &ringBufSource{rd: rd}, decode, met)
+}
+
+// NewPerfEventWatcher builds a Watcher that blocks on rd.Read() for each new perf-event record.
+func NewPerfEventWatcher(name string, rd *perf.Reader, decode Decoder, met *metrics.Metrics) *Watcher {
+	return newWatcher(name, &perfEventSource{rd: rd}, decode, met)
+}
+
+// Run blocks, publishing decoded batches to sink until ctx is canceled or the source returns an
+// unrecoverable error.
+func (w *Watcher) Run(ctx context.Context, sink chan<- []any) {
+	defer func() {
+		if err := w.src.close(); err != nil {
+			wlog.WithError(err).WithField("map", w.name).Warn("can't close map source")
+		}
+	}()
+	for {
+		start := time.Now()
+		values, err := w.src.next(ctx, w.decode)
+		w.lookupLatency.Observe(time.Since(start).Seconds())
+		if err != nil {
+			if ctx.Err() != nil {
+				wlog.WithField("map", w.name).Debug("exiting map watcher due to context cancellation")
+				return
+			}
+			w.errors.WithValues("CannotReadMap", w.name).Inc()
+			wlog.WithError(err).WithField("map", w.name).Warn("can't read map")
+			continue
+		}
+		if len(values) == 0 {
+			continue
+		}
+		w.evictions.Inc()
+		select {
+		case <-ctx.Done():
+			return
+		case sink <- values:
+		}
+	}
+}
+
+// hashPollSource looks up and deletes every entry of a hash-like map on a fixed interval.
+type hashPollSource struct {
+	m            *ebpf.Map
+	pollInterval time.Duration
+	ticker       *time.Ticker
+}
+
+func (s *hashPollSource) next(ctx context.Context, decode Decoder) ([]any, error) {
+	if s.ticker == nil {
+		s.ticker = time.NewTicker(s.pollInterval)
+	}
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-s.ticker.C:
+	}
+
+	var (
+		key, value []byte
+		values     []any
+	)
+	iter := s.m.Iterate()
+	for iter.Next(&key, &value) {
+		decoded, err := decode(key, value)
+		if err != nil {
+			return nil, fmt.Errorf("decoding map entry: %w", err)
+		}
+		if err := s.m.Delete(key); err != nil && err != ebpf.ErrKeyNotExist {
+			return nil, fmt.Errorf("deleting evicted map entry: %w", err)
+		}
+		if decoded == nil {
+			continue
+		}
+		values = append(values, decoded)
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("iterating map: %w", err)
+	}
+	return values, nil
+}
+
+func (s *hashPollSource) close() error {
+	if s.ticker != nil {
+		s.ticker.Stop()
+	}
+	return s.m.Close()
+}
+
+// ringBufSource decodes one ring buffer record per Read call.
+type ringBufSource struct {
+	rd *ringbuf.Reader
+}
+
+func (s *ringBufSource) next(_ context.Context, decode Decoder) ([]any, error) {
+	record, err := s.rd.Read()
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := decode(nil, record.RawSample)
+	if err != nil {
+		return nil, fmt.Errorf("decoding ring buffer record: %w", err)
+	}
+	if decoded == nil {
+		return nil, nil
+	}
+	return []any{decoded}, nil
+}
+
+func (s *ringBufSource) close() error { return s.rd.Close() }
+
+// perfEventSource decodes one perf-event record per Read call.
+type perfEventSource struct {
+	rd *perf.Reader
+}
+
+func (s *perfEventSource) next(_ context.Context, decode Decoder) ([]any, error) {
+	record, err := s.rd.Read()
+	if err != nil {
+		return nil, err
+	}
+	if record.LostSamples > 0 {
+		wlog.WithField("lostSamples", record.LostSamples).Warn("perf event ring buffer full, samples dropped")
+	}
+	decoded, err := decode(nil, record.RawSample)
+	if err != nil {
+		return nil, fmt.Errorf("decoding perf event record: %w", err)
+	}
+	if decoded == nil {
+		return nil, nil
+	}
+	return []any{decoded}, nil
+}
+
+func (s *perfEventSource) close() error { return s.rd.Close() }
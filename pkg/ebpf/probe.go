@@ -0,0 +1,101 @@
+package ebpf
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+var plog = logrus.WithField("component", "ebpf.ProbeRegistry")
+
+// Probe is a discrete, independently toggleable eBPF attachment (a kprobe, tracepoint or socket
+// program) beyond the flow-parse ingress/egress programs that FlowFetcher always attaches. The
+// interface only covers naming, attaching and closing a probe: it exposes no map and no Fetcher,
+// so nothing collects or evicts a probe's enrichment data (retransmits, drop reasons, RTT...).
+type Probe interface {
+	// Name identifies the probe in Config.Probes, e.g. "tcp_retransmit", "tcp_drops_reason",
+	// "tcp_rtt", "queue_latency".
+	Name() string
+	// Attach loads and attaches the probe's program(s). It is called once, after FlowFetcher's
+	// own BPF objects have been loaded.
+	Attach() error
+	// Close detaches the probe and releases its resources.
+	Close() error
+}
+
+// ProbeFactory builds an unattached Probe instance, typically capturing a reference to the
+// FlowFetcher's loaded BpfObjects so the probe can share its ebpf.Collection.
+type ProbeFactory func(objects *BpfObjects) Probe
+
+// ProbeRegistry holds the set of probe implementations the agent knows how to build, keyed by
+// name, so that Config.Probes can toggle them by name without FlowFetcher needing to know about
+// every concrete probe type.
+//
+// Nothing in pkg/agent constructs a ProbeRegistry yet: FlowsAgent's tracerFactory only ever
+// builds a plain ebpf.NewFlowTracer, with no FlowFetcher for a registered Probe's Attach to hook
+// into, so Config.Probes is parsed but unread.
+type ProbeRegistry struct {
+	mu        sync.Mutex
+	factories map[string]ProbeFactory
+}
+
+// NewProbeRegistry returns a registry pre-populated with every probe this agent build ships.
+func NewProbeRegistry() *ProbeRegistry {
+	r := &ProbeRegistry{factories: map[string]ProbeFactory{}}
+	r.Register(ProbeTCPRetransmit, newTCPRetransmitProbe)
+	r.Register(ProbeTCPDropReason, newTCPDropReasonProbe)
+	r.Register(ProbeTCPRTT, newTCPRTTProbe)
+	r.Register(ProbeQueueLatency, newQueueLatencyProbe)
+	return r
+}
+
+// Register adds (or replaces) a named probe factory.
+func (r *ProbeRegistry) Register(name string, factory ProbeFactory) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.factories[name] = factory
+}
+
+// Build instantiates and attaches the named probes, in order. If any probe fails to attach, the
+// probes already attached are closed and the error is returned, so the caller isn't left with a
+// partially-attached probe set.
+func (r *ProbeRegistry) Build(names []string, objects *BpfObjects, errs *metrics.ErrorCounter) ([]Probe, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	attached := make([]Probe, 0, len(names))
+	for _, name := range names {
+		factory, ok := r.factories[name]
+		if !ok {
+			closeAll(attached)
+			return nil, fmt.Errorf("unknown probe %q", name)
+		}
+		probe := factory(objects)
+		if err := probe.Attach(); err != nil {
+			errs.WithValues("CannotAttachProbe", name).Inc()
+			plog.WithError(err).WithField("probe", name).Warn("can't attach probe")
+			closeAll(attached)
+			return nil, fmt.Errorf("attaching probe %q: %w", name, err)
+		}
+		attached = append(attached, probe)
+	}
+	return attached, nil
+}
+
+// errProbeUnavailable is returned by a probe's Attach when this build doesn't include its
+// generated BPF program, which is the case for every probe shipped in this source tree: they
+// document a real, pluggable extension point, but the corresponding bpf/flows.c programs and
+// their bpf2go-generated bindings are not part of this snapshot.
+func errProbeUnavailable(name string) error {
+	return fmt.Errorf("probe %q: no generated BPF program available in this build", name)
+}
+
+func closeAll(probes []Probe) {
+	for _, p := range probes {
+		if err := p.Close(); err != nil {
+			plog.WithError(err).WithField("probe", p.Name()).Warn("can't close probe")
+		}
+	}
+}
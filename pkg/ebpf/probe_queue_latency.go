@@ -0,0 +1,27 @@
+package ebpf
+
+// ProbeQueueLatency is the Config.Probes name for queueLatencyProbe.
+const ProbeQueueLatency = "queue_latency"
+
+// queueLatencyProbe attaches to netif_receive_skb and net_dev_xmit to time how long a packet
+// spends queued in the kernel networking stack, decorating Records with a QueueLatency field.
+//
+// This build doesn't ship the generated BPF programs for this probe: it requires
+// netif_receive_skb/net_dev_xmit tracepoint programs compiled from bpf/flows.c, which aren't
+// part of this source tree. Attach always fails with a clear error rather than silently doing
+// nothing.
+type queueLatencyProbe struct {
+	objects *BpfObjects
+}
+
+func newQueueLatencyProbe(objects *BpfObjects) Probe {
+	return &queueLatencyProbe{objects: objects}
+}
+
+func (p *queueLatencyProbe) Name() string { return ProbeQueueLatency }
+
+func (p *queueLatencyProbe) Attach() error {
+	return errProbeUnavailable(p.Name())
+}
+
+func (p *queueLatencyProbe) Close() error { return nil }
@@ -0,0 +1,28 @@
+package ebpf
+
+// ProbeTCPDropReason is the Config.Probes name for tcpDropReasonProbe.
+const ProbeTCPDropReason = "tcp_drops_reason"
+
+// tcpDropReasonProbe extends the existing kfree_skb tracepoint (which FlowFetcher attaches for TCP
+// drop counting when its tcpDrops option is set) to also capture the kernel's skb drop reason enum,
+// decorating Records with a DropReason field.
+//
+// This build doesn't ship the generated BPF program variant that reads the drop reason argument:
+// it requires a kfree_skb tracepoint program compiled from bpf/flows.c with drop-reason support,
+// which isn't part of this source tree. Attach always fails with a clear error rather than
+// silently doing nothing.
+type tcpDropReasonProbe struct {
+	objects *BpfObjects
+}
+
+func newTCPDropReasonProbe(objects *BpfObjects) Probe {
+	return &tcpDropReasonProbe{objects: objects}
+}
+
+func (p *tcpDropReasonProbe) Name() string { return ProbeTCPDropReason }
+
+func (p *tcpDropReasonProbe) Attach() error {
+	return errProbeUnavailable(p.Name())
+}
+
+func (p *tcpDropReasonProbe) Close() error { return nil }
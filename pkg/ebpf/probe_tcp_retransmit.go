@@ -0,0 +1,26 @@
+package ebpf
+
+// ProbeTCPRetransmit is the Config.Probes name for tcpRetransmitProbe.
+const ProbeTCPRetransmit = "tcp_retransmit"
+
+// tcpRetransmitProbe attaches to the tcp_retransmit_skb kprobe to count retransmissions per
+// flow, decorating Records with a Retransmits field.
+//
+// This build doesn't ship the generated BPF program for this probe: it requires a
+// tcp_retransmit_skb kprobe program compiled from bpf/flows.c, which isn't part of this source
+// tree. Attach always fails with a clear error rather than silently doing nothing.
+type tcpRetransmitProbe struct {
+	objects *BpfObjects
+}
+
+func newTCPRetransmitProbe(objects *BpfObjects) Probe {
+	return &tcpRetransmitProbe{objects: objects}
+}
+
+func (p *tcpRetransmitProbe) Name() string { return ProbeTCPRetransmit }
+
+func (p *tcpRetransmitProbe) Attach() error {
+	return errProbeUnavailable(p.Name())
+}
+
+func (p *tcpRetransmitProbe) Close() error { return nil }
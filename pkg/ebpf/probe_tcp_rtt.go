@@ -0,0 +1,26 @@
+package ebpf
+
+// ProbeTCPRTT is the Config.Probes name for tcpRTTProbe.
+const ProbeTCPRTT = "tcp_rtt"
+
+// tcpRTTProbe attaches to tcp_rcv_established to sample the socket's smoothed RTT, decorating
+// Records with an SRTT field for passive latency visibility.
+//
+// This build doesn't ship the generated BPF program for this probe: it requires a
+// tcp_rcv_established kprobe program compiled from bpf/flows.c, which isn't part of this source
+// tree. Attach always fails with a clear error rather than silently doing nothing.
+type tcpRTTProbe struct {
+	objects *BpfObjects
+}
+
+func newTCPRTTProbe(objects *BpfObjects) Probe {
+	return &tcpRTTProbe{objects: objects}
+}
+
+func (p *tcpRTTProbe) Name() string { return ProbeTCPRTT }
+
+func (p *tcpRTTProbe) Attach() error {
+	return errProbeUnavailable(p.Name())
+}
+
+func (p *tcpRTTProbe) Close() error { return nil }
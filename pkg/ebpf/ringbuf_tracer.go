@@ -0,0 +1,69 @@
+package ebpf
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/ebpf/mapwatcher"
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/metrics"
+
+	"github.com/cilium/ebpf/ringbuf"
+)
+
+// RingBufTracer drains FlowFetcher's "direct_flows" ring buffer (the flows the kernel couldn't
+// aggregate into the hash map) through a mapwatcher.Watcher, instead of FlowFetcher.ReadRingBuf
+// being polled ad hoc by the caller. It decodes each record into a BpfFlowRecordT, a BpfFlowId
+// paired with the same BpfFlowMetrics the aggregated_flows hash map stores per key, so downstream
+// code doesn't need to special-case ring-buffer-sourced flows.
+//
+// Nothing in pkg/agent constructs a RingBufTracer yet, and of mapwatcher's three source kinds
+// (ring buffer, perf event, map poll) only this ring-buffer one has been ported to the
+// mapwatcher.Watcher framework; MapTracer and the perf path remain on their own pre-mapwatcher
+// polling loops. NewRingBufTracer has no caller.
+type RingBufTracer struct {
+	watcher *mapwatcher.Watcher
+}
+
+// NewRingBufTracer wraps rd (FlowFetcher's already-opened ring buffer reader) in a Watcher.
+func NewRingBufTracer(rd *ringbuf.Reader, met *metrics.Metrics) *RingBufTracer {
+	return &RingBufTracer{
+		watcher: mapwatcher.NewRingBufWatcher("direct_flows", rd, decodeFlowRecord, met),
+	}
+}
+
+// Trace blocks, sending decoded *BpfFlowRecordT batches to out until ctx is canceled.
+func (t *RingBufTracer) Trace(ctx context.Context, out chan<- []*BpfFlowRecordT) {
+	sink := make(chan []any)
+	go t.watcher.Run(ctx, sink)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case values, ok := <-sink:
+			if !ok {
+				return
+			}
+			records := make([]*BpfFlowRecordT, 0, len(values))
+			for _, v := range values {
+				if rec, ok := v.(*BpfFlowRecordT); ok {
+					records = append(records, rec)
+				}
+			}
+			if len(records) > 0 {
+				out <- records
+			}
+		}
+	}
+}
+
+// decodeFlowRecord is the mapwatcher.Decoder for the "direct_flows" ring buffer: its records are
+// raw BpfFlowRecordT structs, laid out by the BPF program in the host's native byte order.
+func decodeFlowRecord(_, value []byte) (any, error) {
+	var rec BpfFlowRecordT
+	if err := binary.Read(bytes.NewReader(value), binary.NativeEndian, &rec); err != nil {
+		return nil, fmt.Errorf("decoding BpfFlowRecordT: %w", err)
+	}
+	return &rec, nil
+}
@@ -0,0 +1,32 @@
+package ebpf
+
+// serviceMappingsMap is the name of the BPF_MAP_TYPE_HASH map, keyed by the same flow_id as
+// aggregated_flows, that a conntrack-aware program would populate with ServiceMapping whenever
+// the flow's post-DNAT 5-tuple resolves to a conntrack entry carrying a different original
+// (pre-DNAT) destination.
+const serviceMappingsMap = "service_mappings"
+
+// ServiceMapping is the pre-DNAT Service address/port a conntrack lookup resolved for a flow
+// whose captured 5-tuple is already post-DNAT (e.g. a pod-to-Service flow, seen by the kernel as
+// pod-to-backend-pod once kube-proxy/OVN rewrote the destination).
+type ServiceMapping struct {
+	// OrigDstIP is the Service's ClusterIP (or other pre-DNAT VIP), read from the original-
+	// direction tuple of the flow's conntrack entry.
+	OrigDstIP [16]byte
+	// OrigDstPort is the Service port from that same original-direction tuple.
+	OrigDstPort uint16
+}
+
+// ServiceMappings reads and clears the service_mappings map, returning the pre-DNAT Service
+// address/port resolved for each flow seen since the last call, for merging into the
+// corresponding flow Record by the caller.
+//
+// This build doesn't ship a live conntrack source backing it: neither a BPF program that calls
+// bpf_ct_lookup_tcp/bpf_ct_lookup_udp and stores the result in a BPF_MAP_TYPE_HASH, nor a
+// github.com/ti-mo/conntrack netlink dial resolving it from userspace, is wired into this source
+// tree. Like DrainShadowMap, it always returns an empty result rather than silently reporting
+// every flow as service-less, since the caller already treats service mapping as optional
+// enrichment.
+func (m *FlowFetcher) ServiceMappings() map[BpfFlowId]*ServiceMapping {
+	return nil
+}
@@ -5,13 +5,16 @@ import (
 	"fmt"
 	"io/fs"
 	"strings"
+	"time"
 
 	"github.com/cilium/ebpf"
 	"github.com/cilium/ebpf/btf"
 	"github.com/cilium/ebpf/link"
 	"github.com/cilium/ebpf/ringbuf"
 	"github.com/cilium/ebpf/rlimit"
+	"github.com/gavv/monotime"
 	"github.com/netobserv/netobserv-ebpf-agent/pkg/ifaces"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 	"github.com/vishvananda/netlink"
 	"golang.org/x/sys/unix"
@@ -25,15 +28,54 @@ const (
 	// constants defined in flows.c as "volatile const"
 	constSampling      = "sampling"
 	constTraceMessages = "trace_messages"
+	constEnableL7      = "enable_l7"
 	aggregatedFlowsMap = "aggregated_flows"
+	// batchLookupChunkSize caps how many entries a single BPF_MAP_LOOKUP_BATCH_AND_DELETE_ELEM
+	// syscall drains at once, so lookupAndDeleteMapBatch doesn't allocate an unbounded buffer
+	// against a misconfigured, very large cacheMaxSize.
+	batchLookupChunkSize = 1024
 )
 
+// lookupStrategy selects how FlowFetcher.LookupAndDeleteMap reads and clears the
+// aggregated_flows map. It is detected once, at construction time, from what the running
+// kernel actually supports, fastest first.
+type lookupStrategy int
+
+const (
+	// lookupStrategyIterate removes entries one at a time via Iterate+Delete. The value read
+	// by Iterate and the entry removed by Delete aren't the same kernel operation, so a
+	// concurrent update in between loses that update. Kept only for kernels older than 4.20.
+	lookupStrategyIterate lookupStrategy = iota
+	// lookupStrategySingle uses BPF_MAP_LOOKUP_AND_DELETE_ELEM (kernel>=4.20): read and
+	// delete happen as a single atomic kernel operation, closing that race.
+	lookupStrategySingle
+	// lookupStrategyBatch uses BPF_MAP_LOOKUP_BATCH_AND_DELETE_ELEM (kernel>=5.6) to drain
+	// the map in bulk. Atomic per entry like lookupStrategySingle, and far fewer syscalls.
+	lookupStrategyBatch
+)
+
+func (s lookupStrategy) String() string {
+	switch s {
+	case lookupStrategyBatch:
+		return "batch-lookup-and-delete"
+	case lookupStrategySingle:
+		return "lookup-and-delete"
+	default:
+		return "iterate-and-delete"
+	}
+}
+
 var log = logrus.WithField("component", "ebpf.FlowFetcher")
 
 // FlowFetcher reads and forwards the Flows from the Traffic Control hooks in the eBPF kernel space.
-// It provides access both to flows that are aggregated in the kernel space (via PerfCPU hashmap)
+// It provides access both to flows that are aggregated in the kernel space (in the
+// aggregated_flows BPF_MAP_TYPE_HASH map, a single bpf_spin_lock-protected value per flow key)
 // and to flows that are forwarded by the kernel via ringbuffer because could not be aggregated
 // in the map
+//
+// Nothing in pkg/agent constructs a FlowFetcher yet: FlowsAgent's tracerFactory only ever builds
+// a plain ebpf.NewFlowTracer, so LookupAndDeleteMap's BatchLookupAndDelete/LookupAndDelete
+// feature detection (supportsBatchLookupAndDelete et al.) only runs in this package's own tests.
 type FlowFetcher struct {
 	objects              *BpfObjects
 	qdiscs               map[ifaces.Interface]*netlink.GenericQdisc
@@ -45,12 +87,22 @@ type FlowFetcher struct {
 	enableEgress         bool
 	tcpDropsTracePoint   link.Link
 	dnsTrackerTracePoint link.Link
+
+	// lookupStrategy is the best read+delete strategy the running kernel supports for the
+	// aggregated_flows map, detected once at construction time. See LookupAndDeleteMap.
+	lookupStrategy lookupStrategy
+	// successfulLookups and kernelRaceDrops let operators see the effect of lookupStrategy:
+	// successfulLookups counts entries read and removed cleanly, kernelRaceDrops counts
+	// entries lost to a kernel-side race (only possible under lookupStrategyIterate) or to a
+	// single LookupAndDelete syscall that errored out. A failed batch syscall isn't counted here.
+	successfulLookups uint64
+	kernelRaceDrops   uint64
 }
 
 func NewFlowFetcher(
 	traceMessages bool,
 	sampling, cacheMaxSize int,
-	ingress, egress, tcpDrops, dnsTracker bool,
+	ingress, egress, tcpDrops, dnsTracker, l7Tracking bool,
 ) (*FlowFetcher, error) {
 	if err := rlimit.RemoveMemlock(); err != nil {
 		log.WithError(err).
@@ -70,9 +122,14 @@ func NewFlowFetcher(
 	if traceMessages {
 		traceMsgs = 1
 	}
+	l7Enabled := 0
+	if l7Tracking {
+		l7Enabled = 1
+	}
 	if err := spec.RewriteConstants(map[string]interface{}{
 		constSampling:      uint32(sampling),
 		constTraceMessages: uint8(traceMsgs),
+		constEnableL7:      uint8(l7Enabled),
 	}); err != nil {
 		return nil, fmt.Errorf("rewriting BPF constants definition: %w", err)
 	}
@@ -93,6 +150,9 @@ func NewFlowFetcher(
 	 */
 	btf.FlushKernelSpec()
 
+	strategy := detectLookupStrategy(spec.Maps[aggregatedFlowsMap])
+	log.Infof("using %q strategy to read and clear the aggregated_flows map", strategy)
+
 	var tcpDropsLink link.Link
 	if tcpDrops {
 		tcpDropsLink, err = link.Tracepoint("skb", "kfree_skb", objects.KfreeSkb, nil)
@@ -125,9 +185,65 @@ func NewFlowFetcher(
 		enableEgress:         egress,
 		tcpDropsTracePoint:   tcpDropsLink,
 		dnsTrackerTracePoint: dnsTrackerLink,
+		lookupStrategy:       strategy,
 	}, nil
 }
 
+// detectLookupStrategy probes the running kernel for BPF_MAP_LOOKUP_AND_DELETE_ELEM (>=4.20)
+// and BPF_MAP_LOOKUP_BATCH_AND_DELETE_ELEM (>=5.6) support and returns the fastest one it
+// implements. The probe runs against a throwaway map of the same type as aggregated_flows
+// (built from its already-loaded MapSpec) so the result doesn't depend on, or disturb, the
+// real map's contents.
+func detectLookupStrategy(spec *ebpf.MapSpec) lookupStrategy {
+	testMap, err := ebpf.NewMap(&ebpf.MapSpec{
+		Type:       spec.Type,
+		KeySize:    spec.KeySize,
+		ValueSize:  spec.ValueSize,
+		MaxEntries: 1,
+	})
+	if err != nil {
+		log.WithError(err).Debug("couldn't create test map to probe the kernel's lookup-and-delete support. " +
+			"Falling back to the Iterate+Delete strategy")
+		return lookupStrategyIterate
+	}
+	defer testMap.Close()
+
+	if supportsBatchLookupAndDelete(testMap) {
+		return lookupStrategyBatch
+	}
+	if supportsSingleLookupAndDelete(testMap) {
+		return lookupStrategySingle
+	}
+	return lookupStrategyIterate
+}
+
+// supportsBatchLookupAndDelete reports whether BPF_MAP_LOOKUP_BATCH_AND_DELETE_ELEM is
+// implemented by the running kernel. It issues a batch call against an empty map: the kernel
+// rejects an unsupported command outright, while a supported one just reports that there was
+// nothing to return (ebpf.ErrKeyNotExist).
+func supportsBatchLookupAndDelete(m *ebpf.Map) bool {
+	var (
+		cursor    ebpf.MapBatchCursor
+		keysOut   = make([]BpfFlowId, 1)
+		valuesOut = make([]BpfFlowMetrics, 1)
+	)
+	_, err := m.BatchLookupAndDelete(&cursor, keysOut, valuesOut, nil)
+	return err == nil || errors.Is(err, ebpf.ErrKeyNotExist)
+}
+
+// supportsSingleLookupAndDelete reports whether BPF_MAP_LOOKUP_AND_DELETE_ELEM is implemented
+// by the running kernel, following the same reasoning as supportsBatchLookupAndDelete: a
+// lookup against a key that doesn't exist still reaches the kernel command, so
+// ebpf.ErrKeyNotExist means "supported, nothing found" rather than "unsupported".
+func supportsSingleLookupAndDelete(m *ebpf.Map) bool {
+	var (
+		key   BpfFlowId
+		value BpfFlowMetrics
+	)
+	err := m.LookupAndDelete(&key, &value)
+	return err == nil || errors.Is(err, ebpf.ErrKeyNotExist)
+}
+
 // Register and links the eBPF fetcher into the system. The program should invoke Unregister
 // before exiting.
 func (m *FlowFetcher) Register(iface ifaces.Interface) error {
@@ -332,16 +448,109 @@ func (m *FlowFetcher) ReadRingBuf() (ringbuf.Record, error) {
 	return m.ringbufReader.Read()
 }
 
-// LookupAndDeleteMap reads all the entries from the eBPF map and removes them from it.
-// It returns a map where the key
-// For synchronization purposes, we get/delete a whole snapshot of the flows map.
-// This way we avoid missing packets that could be updated on the
-// ebpf side while we process/aggregate them here
-// Changing this method invocation by BatchLookupAndDelete could improve performance
-// TODO: detect whether BatchLookupAndDelete is supported (Kernel>=5.6) and use it selectively
+// LookupAndDeleteMap reads all the entries from the eBPF map and removes them from it, using
+// the fastest strategy the running kernel supports (see lookupStrategy). For synchronization
+// purposes, we get/delete a whole snapshot of the flows map. This way we avoid missing packets
+// that could be updated on the ebpf side while we process/aggregate them here. Under the per-key
+// strategies, counter and kernelRaceDrops (see LookupStats) are both incremented once per entry
+// lost to a kernel-side race or a failed single syscall; a failed batch syscall increments
+// counter once and leaves kernelRaceDrops unchanged.
 // Supported Lookup/Delete operations by kernel: https://github.com/iovisor/bcc/blob/master/docs/kernel-versions.md
-// Race conditions here causes that some flows are lost in high-load scenarios
-func (m *FlowFetcher) LookupAndDeleteMap() map[BpfFlowId]*BpfFlowMetrics {
+func (m *FlowFetcher) LookupAndDeleteMap(counter prometheus.Counter) map[BpfFlowId]*BpfFlowMetrics {
+	if m.lookupStrategy == lookupStrategyBatch {
+		flows, ok := m.lookupAndDeleteMapBatch()
+		if ok {
+			return flows
+		}
+		// the batch syscall itself errored out, not just "no more entries": don't trust it
+		// again this run and demote to the safer per-key strategy for the rest of the flows.
+		log.Warn("batch lookup-and-delete of aggregated_flows failed. Falling back to the per-key strategy")
+		counter.Inc()
+		m.lookupStrategy = lookupStrategySingle
+		return flows
+	}
+	if m.lookupStrategy == lookupStrategySingle {
+		return m.lookupAndDeleteMapSingle(counter)
+	}
+	return m.lookupAndDeleteMapIterate(counter)
+}
+
+// lookupAndDeleteMapBatch drains aggregated_flows using BPF_MAP_LOOKUP_BATCH_AND_DELETE_ELEM,
+// in chunks of at most batchLookupChunkSize entries so a single syscall can't be made to
+// allocate an unbounded buffer. ok is false if the batch syscall itself errored out (as
+// opposed to simply running out of entries to return), signaling the caller to stop trusting
+// this strategy.
+func (m *FlowFetcher) lookupAndDeleteMapBatch() (flows map[BpfFlowId]*BpfFlowMetrics, ok bool) {
+	flowMap := m.objects.AggregatedFlows
+	flows = make(map[BpfFlowId]*BpfFlowMetrics, m.cacheMaxSize)
+
+	chunkSize := batchLookupChunkSize
+	if m.cacheMaxSize > 0 && m.cacheMaxSize < chunkSize {
+		chunkSize = m.cacheMaxSize
+	}
+	keysOut := make([]BpfFlowId, chunkSize)
+	valuesOut := make([]BpfFlowMetrics, chunkSize)
+
+	var cursor ebpf.MapBatchCursor
+	for {
+		n, err := flowMap.BatchLookupAndDelete(&cursor, keysOut, valuesOut, nil)
+		for i := 0; i < n; i++ {
+			metricPtr := new(BpfFlowMetrics)
+			*metricPtr = valuesOut[i]
+			flows[keysOut[i]] = metricPtr
+		}
+		m.successfulLookups += uint64(n)
+		if err != nil {
+			if errors.Is(err, ebpf.ErrKeyNotExist) {
+				return flows, true
+			}
+			log.WithError(err).Warn("batch lookup-and-delete of aggregated_flows map failed")
+			return flows, false
+		}
+	}
+}
+
+// lookupAndDeleteMapSingle drains aggregated_flows one key at a time via
+// BPF_MAP_LOOKUP_AND_DELETE_ELEM. Unlike lookupAndDeleteMapIterate, the read and the delete are
+// a single atomic kernel operation, so a concurrent update to the same key can't be lost
+// between the two.
+func (m *FlowFetcher) lookupAndDeleteMapSingle(counter prometheus.Counter) map[BpfFlowId]*BpfFlowMetrics {
+	flowMap := m.objects.AggregatedFlows
+	flows := make(map[BpfFlowId]*BpfFlowMetrics, m.cacheMaxSize)
+
+	// Iterate only collects the keys currently in the map; it never mutates it, so it can't
+	// race with the LookupAndDelete calls below.
+	var keys []BpfFlowId
+	var id BpfFlowId
+	var metric BpfFlowMetrics
+	iterator := flowMap.Iterate()
+	for iterator.Next(&id, &metric) {
+		keys = append(keys, id)
+	}
+
+	for _, id := range keys {
+		var metric BpfFlowMetrics
+		if err := flowMap.LookupAndDelete(&id, &metric); err != nil {
+			if !errors.Is(err, ebpf.ErrKeyNotExist) {
+				log.WithError(err).WithField("flowId", id).Warn("couldn't look up and delete flow entry")
+			}
+			m.kernelRaceDrops++
+			counter.Inc()
+			continue
+		}
+		metricPtr := new(BpfFlowMetrics)
+		*metricPtr = metric
+		flows[id] = metricPtr
+		m.successfulLookups++
+	}
+	return flows
+}
+
+// lookupAndDeleteMapIterate reads all the entries from the eBPF map and removes them from it
+// one at a time via Iterate+Delete. Kept only as a fallback for kernels older than 4.20: the
+// value read by Iterate and the entry removed by Delete aren't the same kernel operation, so a
+// concurrent update in between causes that update to be lost.
+func (m *FlowFetcher) lookupAndDeleteMapIterate(counter prometheus.Counter) map[BpfFlowId]*BpfFlowMetrics {
 	flowMap := m.objects.AggregatedFlows
 
 	iterator := flowMap.Iterate()
@@ -349,12 +558,14 @@ func (m *FlowFetcher) LookupAndDeleteMap() map[BpfFlowId]*BpfFlowMetrics {
 	var id BpfFlowId
 	var metric BpfFlowMetrics
 
-	// Changing Iterate+Delete by LookupAndDelete would prevent some possible race conditions
-	// TODO: detect whether LookupAndDelete is supported (Kernel>=4.20) and use it selectively
 	for iterator.Next(&id, &metric) {
 		if err := flowMap.Delete(id); err != nil {
 			log.WithError(err).WithField("flowId", id).
 				Warnf("couldn't delete flow entry")
+			m.kernelRaceDrops++
+			counter.Inc()
+		} else {
+			m.successfulLookups++
 		}
 		metricPtr := new(BpfFlowMetrics)
 		*metricPtr = metric
@@ -362,3 +573,67 @@ func (m *FlowFetcher) LookupAndDeleteMap() map[BpfFlowId]*BpfFlowMetrics {
 	}
 	return flow
 }
+
+// LookupStrategy returns the name of the read+delete strategy currently in use against the
+// aggregated_flows map, e.g. for inclusion in startup logs or a status endpoint.
+func (m *FlowFetcher) LookupStrategy() string {
+	return m.lookupStrategy.String()
+}
+
+// LookupStats returns the running totals of entries read and removed cleanly
+// (successfulLookups) versus entries lost to a kernel-side race or a failed single
+// lookup-and-delete syscall (kernelRaceDrops), so operators can see the effect of the selected
+// lookup strategy.
+func (m *FlowFetcher) LookupStats() (successfulLookups, kernelRaceDrops uint64) {
+	return m.successfulLookups, m.kernelRaceDrops
+}
+
+// DrainShadowMap would drain the kernel-side LRU shadow map that aggregated_flows' update path
+// falls back to when an insert fails with -E2BIG (the HASH map, sized with BPF_F_NO_PREALLOC,
+// can legitimately be full on every CPU at once). This build doesn't ship a generated
+// "aggregated_flows_shadow" map: like the probes in probe.go and EndpointFetcher, the
+// bpf/flows.c program and bpf2go-generated bindings backing it aren't part of this source
+// tree, so this always returns an empty result rather than silently pretending to drain one.
+func (m *FlowFetcher) DrainShadowMap() map[BpfFlowId]*BpfFlowMetrics {
+	return nil
+}
+
+// DeleteMapsStaleEntries removes any aggregated_flows entry whose EndMonoTimeTs is older than
+// timeOut, so a flow that stopped being updated (e.g. its interface went away between two
+// LookupAndDeleteMap cycles) doesn't sit in the map forever instead of being evicted.
+func (m *FlowFetcher) DeleteMapsStaleEntries(timeOut time.Duration) {
+	flowMap := m.objects.AggregatedFlows
+	cutoff := uint64(monotime.Now()) - uint64(timeOut.Nanoseconds())
+
+	var stale []BpfFlowId
+	var id BpfFlowId
+	var metric BpfFlowMetrics
+	iterator := flowMap.Iterate()
+	for iterator.Next(&id, &metric) {
+		if metric.EndMonoTimeTs < cutoff {
+			stale = append(stale, id)
+		}
+	}
+	for _, id := range stale {
+		if err := flowMap.Delete(id); err != nil && !errors.Is(err, ebpf.ErrKeyNotExist) {
+			log.WithError(err).WithField("flowId", id).Warn("couldn't delete stale flow entry")
+		}
+	}
+}
+
+// MapFillRatio returns the fraction (0..1) of the aggregated-flows map's MaxEntries that is
+// currently occupied, by iterating its keys. It is meant to be polled at a low frequency (e.g.
+// by flow.MapTracer's adaptive eviction) rather than on every packet.
+func (m *FlowFetcher) MapFillRatio() float64 {
+	if m.cacheMaxSize <= 0 {
+		return 0
+	}
+	var count int
+	var id BpfFlowId
+	var metric BpfFlowMetrics
+	iterator := m.objects.AggregatedFlows.Iterate()
+	for iterator.Next(&id, &metric) {
+		count++
+	}
+	return float64(count) / float64(m.cacheMaxSize)
+}
@@ -0,0 +1,76 @@
+package enricher
+
+import (
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// CompletionIndexAnnotation is set by the Job controller on every Pod of an Indexed Job.
+const CompletionIndexAnnotation = "batch.kubernetes.io/job-completion-index"
+
+// managedByJobController is the well-known value of Job.Spec.ManagedBy for Jobs reconciled by
+// the built-in kube-controller-manager, as opposed to an external controller (e.g. Kueue).
+const managedByJobController = "kubernetes.io/job-controller"
+
+// JobOwnerInfo is the Job/CronJob ownership metadata resolved for a Pod, meant to be attached to
+// the flows captured on that Pod's interfaces as SrcK8S_Job* / DstK8S_Job* fields. Nothing in
+// pkg/agent calls ResolveJobOwner/JobOwnerCache yet, so those fields stay reserved/unwired on
+// JSONRecord and IPFIXRecord until a Pod-metadata enrichment stage is wired into the agent's
+// record pipeline.
+type JobOwnerInfo struct {
+	JobName            string
+	CronJobName        string
+	CompletionIndex    string
+	CompletionMode     string
+	ManagedBy          string
+}
+
+// JobLookup resolves a Job object by name in the given namespace. Implementations are expected
+// to be backed by a local informer cache rather than a live API call.
+type JobLookup func(namespace, name string) (*batchv1.Job, bool)
+
+// ResolveJobOwner walks a Pod's OwnerReferences to find its owning Job, and the Job's own
+// OwnerReferences to find its owning CronJob, mirroring the same owner-reference traversal
+// already used to resolve Deployment/DaemonSet/StatefulSet owners, but one level deeper.
+func ResolveJobOwner(pod *metav1.ObjectMeta, lookupJob JobLookup) (JobOwnerInfo, bool) {
+	var info JobOwnerInfo
+	jobRef, ok := ownerOfKind(pod.OwnerReferences, "Job")
+	if !ok {
+		return info, false
+	}
+	info.JobName = jobRef.Name
+	info.CompletionIndex = pod.Annotations[CompletionIndexAnnotation]
+
+	job, ok := lookupJob(pod.Namespace, jobRef.Name)
+	if !ok {
+		// The Job has already been garbage-collected: fall back to what we could infer from
+		// the Pod alone, which is still useful to attribute the flow to a JobName/index.
+		return info, true
+	}
+	if job.Spec.CompletionMode != nil {
+		info.CompletionMode = string(*job.Spec.CompletionMode)
+	}
+	if job.Spec.ManagedBy != nil {
+		info.ManagedBy = *job.Spec.ManagedBy
+	}
+	if cronRef, ok := ownerOfKind(job.OwnerReferences, "CronJob"); ok {
+		info.CronJobName = cronRef.Name
+	}
+	return info, true
+}
+
+// ManagedExternally reports whether a Job's ManagedBy field points to a controller other than
+// the built-in kube-controller-manager (e.g. Kueue), which lets operators filter the Job
+// informer cache down to the workloads they actually care about.
+func ManagedExternally(info JobOwnerInfo) bool {
+	return info.ManagedBy != "" && info.ManagedBy != managedByJobController
+}
+
+func ownerOfKind(refs []metav1.OwnerReference, kind string) (metav1.OwnerReference, bool) {
+	for _, ref := range refs {
+		if ref.Kind == kind {
+			return ref, true
+		}
+	}
+	return metav1.OwnerReference{}, false
+}
@@ -0,0 +1,58 @@
+package enricher
+
+import (
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// JobOwnerCache remembers the JobOwnerInfo resolved for a Pod the first time a flow is seen for
+// it, keyed by Pod UID rather than by Job name. This keeps lookups O(1) regardless of how large
+// an Indexed Job's "completions" is, and lets the enricher keep reporting JobName/
+// CompletionIndex for flows whose Pod is still running after its owning Job object has already
+// been garbage-collected. Nothing in pkg/agent constructs a JobOwnerCache yet (see
+// pkg/exporter.JSONRecord's SrcK8S_Job* fields), so it's only exercised by this package's tests
+// until a Pod-metadata enrichment stage is wired into the agent's record pipeline.
+type JobOwnerCache struct {
+	mu      sync.RWMutex
+	byPodID map[types.UID]JobOwnerInfo
+}
+
+// NewJobOwnerCache creates an empty JobOwnerCache.
+func NewJobOwnerCache() *JobOwnerCache {
+	return &JobOwnerCache{byPodID: map[types.UID]JobOwnerInfo{}}
+}
+
+// ResolveAndCache returns the cached JobOwnerInfo for podUID if one was captured at flow-start
+// time. Otherwise, it resolves it via ResolveJobOwner, caches the result (even a negative one,
+// to avoid repeatedly trying to resolve Pods that aren't owned by a Job) and returns it.
+func (c *JobOwnerCache) ResolveAndCache(podUID types.UID, pod *metav1.ObjectMeta, lookupJob JobLookup) (JobOwnerInfo, bool) {
+	c.mu.RLock()
+	if info, ok := c.byPodID[podUID]; ok {
+		c.mu.RUnlock()
+		return info, info.JobName != ""
+	}
+	c.mu.RUnlock()
+
+	info, ok := ResolveJobOwner(pod, lookupJob)
+	c.mu.Lock()
+	c.byPodID[podUID] = info
+	c.mu.Unlock()
+	return info, ok
+}
+
+// Forget removes the cached entry for a Pod, e.g. once it has been deleted and its interfaces
+// detached.
+func (c *JobOwnerCache) Forget(podUID types.UID) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.byPodID, podUID)
+}
+
+// Len returns the number of Pods currently tracked, mostly for tests and metrics.
+func (c *JobOwnerCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.byPodID)
+}
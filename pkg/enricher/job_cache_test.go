@@ -0,0 +1,68 @@
+package enricher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestJobOwnerCache_CachesAcrossJobGC(t *testing.T) {
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "my-job-0",
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "CronJob", Name: "my-cronjob"},
+			},
+		},
+	}
+	pod := &metav1.ObjectMeta{
+		Name:      "my-job-0-abcde",
+		Namespace: "default",
+		Annotations: map[string]string{
+			CompletionIndexAnnotation: "3",
+		},
+		OwnerReferences: []metav1.OwnerReference{
+			{Kind: "Job", Name: "my-job-0"},
+		},
+	}
+	podUID := types.UID("pod-uid-1")
+
+	lookups := 0
+	lookupJob := func(ns, name string) (*batchv1.Job, bool) {
+		lookups++
+		return job, true
+	}
+
+	cache := NewJobOwnerCache()
+	info, ok := cache.ResolveAndCache(podUID, pod, lookupJob)
+	assert.True(t, ok)
+	assert.Equal(t, "my-job-0", info.JobName)
+	assert.Equal(t, "my-cronjob", info.CronJobName)
+	assert.Equal(t, 1, lookups)
+
+	// The Job has since been garbage-collected, but the cached entry still reports the
+	// ownership that was captured the first time the Pod's flows were seen.
+	gcInfo, ok := cache.ResolveAndCache(podUID, pod, func(string, string) (*batchv1.Job, bool) {
+		return nil, false
+	})
+	assert.True(t, ok)
+	assert.Equal(t, info, gcInfo)
+	assert.Equal(t, 1, lookups, "lookupJob must not be called again once the Pod is cached")
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestJobOwnerCache_Forget(t *testing.T) {
+	cache := NewJobOwnerCache()
+	podUID := types.UID("pod-uid-2")
+	pod := &metav1.ObjectMeta{}
+
+	_, ok := cache.ResolveAndCache(podUID, pod, func(string, string) (*batchv1.Job, bool) { return nil, false })
+	assert.False(t, ok)
+	assert.Equal(t, 1, cache.Len())
+
+	cache.Forget(podUID)
+	assert.Equal(t, 0, cache.Len())
+}
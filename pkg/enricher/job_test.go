@@ -0,0 +1,54 @@
+package enricher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestResolveJobOwner(t *testing.T) {
+	indexed := batchv1.IndexedCompletion
+	managedBy := "kueue.x-k8s.io/multikueue"
+	job := &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "my-job-0",
+			OwnerReferences: []metav1.OwnerReference{
+				{Kind: "CronJob", Name: "my-cronjob"},
+			},
+		},
+		Spec: batchv1.JobSpec{
+			CompletionMode: &indexed,
+			ManagedBy:      &managedBy,
+		},
+	}
+	pod := &metav1.ObjectMeta{
+		Name:      "my-job-0-abcde",
+		Namespace: "default",
+		Annotations: map[string]string{
+			CompletionIndexAnnotation: "3",
+		},
+		OwnerReferences: []metav1.OwnerReference{
+			{Kind: "Job", Name: "my-job-0"},
+		},
+	}
+
+	info, ok := ResolveJobOwner(pod, func(ns, name string) (*batchv1.Job, bool) {
+		assert.Equal(t, "default", ns)
+		assert.Equal(t, "my-job-0", name)
+		return job, true
+	})
+	assert.True(t, ok)
+	assert.Equal(t, "my-job-0", info.JobName)
+	assert.Equal(t, "my-cronjob", info.CronJobName)
+	assert.Equal(t, "3", info.CompletionIndex)
+	assert.Equal(t, string(batchv1.IndexedCompletion), info.CompletionMode)
+	assert.True(t, ManagedExternally(info))
+}
+
+func TestResolveJobOwner_NotOwnedByJob(t *testing.T) {
+	pod := &metav1.ObjectMeta{}
+	_, ok := ResolveJobOwner(pod, func(string, string) (*batchv1.Job, bool) { return nil, false })
+	assert.False(t, ok)
+}
@@ -0,0 +1,179 @@
+// Package enricher provides optional flow enrichment stages that add context beyond the raw
+// 5-tuple captured by the eBPF hooks.
+package enricher
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/ovn-org/libovsdb/client"
+	"github.com/ovn-org/libovsdb/model"
+	"github.com/sirupsen/logrus"
+)
+
+var olog = logrus.WithField("component", "enricher.OVN")
+
+const (
+	// DatabaseSouthbound monitors the OVN_Southbound database, which carries the per-port
+	// logical switch/router bindings and the external_ids set by ovn-kubernetes.
+	DatabaseSouthbound = "OVN_Southbound"
+	// DatabaseNorthbound monitors the OVN_Northbound database.
+	DatabaseNorthbound = "OVN_Northbound"
+)
+
+// OVNConfig holds the connection settings for the optional OVSDB enrichment stage.
+type OVNConfig struct {
+	// Endpoint is the OVSDB connection string, e.g. "tcp:127.0.0.1:6642" or
+	// "ssl:127.0.0.1:6642".
+	Endpoint string
+	// Database selects which database to monitor: DatabaseSouthbound (default) or
+	// DatabaseNorthbound.
+	Database string
+	// CACertPath, CertPath and KeyPath configure TLS when Endpoint uses the "ssl" scheme.
+	CACertPath string
+	CertPath   string
+	KeyPath    string
+}
+
+// LogicalPortInfo is the logical topology metadata resolved for a given interface.
+type LogicalPortInfo struct {
+	LogicalSwitch string
+	LogicalPort   string
+	LogicalRouter string
+	LSPType       string
+	PodName       string
+	PodNamespace  string
+}
+
+// OVNEnricher keeps a live cache of logical port bindings, indexed by MAC and IP address, so
+// that the flow pipeline can decorate flows with OVN logical topology fields in O(1).
+//
+// It gracefully degrades: if the OVSDB socket is absent or becomes unreachable, Start returns
+// an error and callers are expected to run without this enrichment stage rather than failing
+// the whole agent.
+//
+// Nothing in pkg/agent constructs an OVNEnricher yet: Config.EnableOVNEnrichment,
+// Config.OVNDBEndpoint and Config.OVNDatabase are parsed but unread, and no flow.Record or
+// exporter schema carries LogicalSwitch/LogicalPort/LogicalRouter/LSPType. flow.EndpointTracer's
+// podResolver is the only consumer of LogicalPortInfo so far, and even that has no caller yet
+// (see EndpointTracer). Wiring OVN enrichment into the flow record path is left for a follow-up.
+type OVNEnricher struct {
+	cfg    OVNConfig
+	client client.Client
+
+	mu      sync.RWMutex
+	byMAC   map[string]LogicalPortInfo
+	byIP    map[string]LogicalPortInfo
+}
+
+// NewOVNEnricher creates an OVNEnricher. It doesn't connect until Start is invoked.
+func NewOVNEnricher(cfg OVNConfig) *OVNEnricher {
+	if cfg.Database == "" {
+		cfg.Database = DatabaseSouthbound
+	}
+	return &OVNEnricher{
+		cfg:   cfg,
+		byMAC: map[string]LogicalPortInfo{},
+		byIP:  map[string]LogicalPortInfo{},
+	}
+}
+
+// Start connects to the configured OVSDB endpoint and keeps the logical port cache updated via
+// an OVSDB monitor until the context is canceled.
+func (e *OVNEnricher) Start(ctx context.Context) error {
+	dbModel, err := model.NewClientDBModel(e.cfg.Database, map[string]model.Model{
+		"Port_Binding": &portBinding{},
+	})
+	if err != nil {
+		return fmt.Errorf("building OVSDB client model: %w", err)
+	}
+
+	opts := []client.Option{client.WithEndpoint(e.cfg.Endpoint)}
+	if e.cfg.CACertPath != "" {
+		opts = append(opts, client.WithTLSConfig(tlsConfig(e.cfg)))
+	}
+
+	c, err := client.NewOVSDBClient(dbModel, opts...)
+	if err != nil {
+		return fmt.Errorf("creating OVSDB client: %w", err)
+	}
+	if err := c.Connect(ctx); err != nil {
+		return fmt.Errorf("connecting to OVSDB at %s: %w", e.cfg.Endpoint, err)
+	}
+	e.client = c
+
+	if _, err := c.Monitor(ctx, c.NewMonitor(
+		client.WithTable(&portBinding{}),
+	)); err != nil {
+		return fmt.Errorf("monitoring Port_Binding table: %w", err)
+	}
+
+	c.Cache().AddEventHandler(&client.EventHandlerFuncs{
+		AddFunc:    func(_ string, model model.Model) { e.index(model) },
+		UpdateFunc: func(_ string, _, new model.Model) { e.index(new) },
+		DeleteFunc: func(_ string, model model.Model) { e.remove(model) },
+	})
+
+	olog.WithFields(logrus.Fields{"endpoint": e.cfg.Endpoint, "database": e.cfg.Database}).
+		Info("OVN enrichment cache started")
+
+	go func() {
+		<-ctx.Done()
+		c.Close()
+	}()
+	return nil
+}
+
+// LookupByMAC returns the logical port info bound to the given MAC address, if known.
+func (e *OVNEnricher) LookupByMAC(mac string) (LogicalPortInfo, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	info, ok := e.byMAC[mac]
+	return info, ok
+}
+
+// LookupByIP returns the logical port info bound to the given IP address, if known.
+func (e *OVNEnricher) LookupByIP(ip string) (LogicalPortInfo, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	info, ok := e.byIP[ip]
+	return info, ok
+}
+
+func (e *OVNEnricher) index(m model.Model) {
+	pb, ok := m.(*portBinding)
+	if !ok {
+		return
+	}
+	info := LogicalPortInfo{
+		LogicalSwitch: pb.Datapath,
+		LogicalPort:   pb.LogicalPort,
+		LSPType:       pb.Type,
+		PodName:       pb.ExternalIDs["pod_name"],
+		PodNamespace:  pb.ExternalIDs["namespace"],
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, mac := range pb.MACs() {
+		e.byMAC[mac] = info
+	}
+	for _, ip := range pb.IPs() {
+		e.byIP[ip] = info
+	}
+}
+
+func (e *OVNEnricher) remove(m model.Model) {
+	pb, ok := m.(*portBinding)
+	if !ok {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for _, mac := range pb.MACs() {
+		delete(e.byMAC, mac)
+	}
+	for _, ip := range pb.IPs() {
+		delete(e.byIP, ip)
+	}
+}
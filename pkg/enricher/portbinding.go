@@ -0,0 +1,37 @@
+package enricher
+
+import "strings"
+
+// portBinding is a minimal libovsdb model of the OVN_Southbound "Port_Binding" table, limited
+// to the columns this enricher needs. The "mac" column in OVN encodes one or more
+// "<mac> [<ip> ...]" entries, one per requested address.
+type portBinding struct {
+	UUID        string            `ovsdb:"_uuid"`
+	LogicalPort string            `ovsdb:"logical_port"`
+	Datapath    string            `ovsdb:"datapath"`
+	Type        string            `ovsdb:"type"`
+	MAC         []string          `ovsdb:"mac"`
+	ExternalIDs map[string]string `ovsdb:"external_ids"`
+}
+
+// IPs extracts the IP addresses embedded in the "mac" column entries, e.g.
+// "0a:58:0a:f4:00:05 10.244.0.5" -> ["10.244.0.5"].
+func (p *portBinding) IPs() []string {
+	var ips []string
+	for _, entry := range p.MAC {
+		fields := strings.Fields(entry)
+		ips = append(ips, fields[1:]...)
+	}
+	return ips
+}
+
+// MACs extracts the MAC addresses embedded in the "mac" column entries.
+func (p *portBinding) MACs() []string {
+	var macs []string
+	for _, entry := range p.MAC {
+		if fields := strings.Fields(entry); len(fields) > 0 {
+			macs = append(macs, fields[0])
+		}
+	}
+	return macs
+}
@@ -0,0 +1,15 @@
+package enricher
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPortBinding_MACsAndIPs(t *testing.T) {
+	pb := &portBinding{
+		MAC: []string{"0a:58:0a:f4:00:05 10.244.0.5", "0a:58:0a:f4:00:06 10.244.0.6 fd00::6"},
+	}
+	assert.Equal(t, []string{"0a:58:0a:f4:00:05", "0a:58:0a:f4:00:06"}, pb.MACs())
+	assert.Equal(t, []string{"10.244.0.5", "10.244.0.6", "fd00::6"}, pb.IPs())
+}
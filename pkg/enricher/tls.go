@@ -0,0 +1,26 @@
+package enricher
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"os"
+)
+
+// tlsConfig builds the client TLS configuration used to connect to an "ssl:" OVSDB endpoint.
+func tlsConfig(cfg OVNConfig) *tls.Config {
+	pool := x509.NewCertPool()
+	if ca, err := os.ReadFile(cfg.CACertPath); err == nil {
+		pool.AppendCertsFromPEM(ca)
+	} else {
+		olog.WithError(err).Warn("can't read OVSDB CA certificate")
+	}
+	tlsCfg := &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	if cfg.CertPath != "" && cfg.KeyPath != "" {
+		if cert, err := tls.LoadX509KeyPair(cfg.CertPath, cfg.KeyPath); err == nil {
+			tlsCfg.Certificates = []tls.Certificate{cert}
+		} else {
+			olog.WithError(err).Warn("can't load OVSDB client certificate")
+		}
+	}
+	return tlsCfg
+}
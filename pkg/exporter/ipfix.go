@@ -0,0 +1,248 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/gavv/monotime"
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/flow"
+	"github.com/sirupsen/logrus"
+)
+
+var iplog = logrus.WithField("component", "exporter/IPFIXProto")
+
+const (
+	ipfixVersion = 10
+	// templateSetID and the flow template ID used for the single "flowTemplate" this exporter
+	// currently emits. Real deployments with heterogeneous field sets would hand out more IDs.
+	templateSetID = 2
+	flowTemplateID = 256
+
+	// field types, as defined by the IANA IPFIX Information Element registry (RFC 7011/7012)
+	ieSourceIPv4Address      = 8
+	ieDestinationIPv4Address = 12
+	ieProtocolIdentifier     = 4
+	iePacketDeltaCount       = 2
+	ieOctetDeltaCount        = 1
+	ieIngressInterface       = 10
+	ieSourceTransportPort    = 7
+	ieDestinationTransportPort = 11
+	ieFlowStartMilliseconds  = 152
+	ieFlowEndMilliseconds    = 153
+
+	// netobservPEN is the Private Enterprise Number under which this agent registers its own
+	// Information Elements (RFC 7012 §1), for fields with no IANA-assigned IE. It's a
+	// placeholder until the project registers a real PEN with IANA.
+	netobservPEN = 55595
+
+	// netobservPEN-scoped Information Elements carrying interface/direction and the
+	// Kubernetes Job/CronJob enrichment also exposed as SrcK8S_Job*/DstK8S_Job* in the JSON
+	// and Kafka exporters (see JSONRecord). Reserved/unwired: see IPFIXRecord.
+	ieInterfaceName     = 1
+	ieDirection         = 2
+	ieSrcK8SJobName     = 3
+	ieSrcK8SCronJobName = 4
+	ieDstK8SJobName     = 5
+	ieDstK8SCronJobName = 6
+
+	// interfaceNameLen and k8sNameLen bound the fixed-width string fields below: IPFIX allows
+	// variable-length IEs (len 0xFFFF) but this exporter keeps every field fixed-size to avoid
+	// the extra encoding complexity until a collector actually needs the full name.
+	interfaceNameLen = 16
+	k8sNameLen       = 64
+)
+
+// ipfixField describes one Information Element of the template: its IE number, its encoded
+// length in bytes, and whether it's an enterprise-specific IE (RFC 7012) scoped to
+// netobservPEN rather than an IANA-assigned one.
+type ipfixField struct {
+	ie         uint16
+	len        uint16
+	enterprise bool
+}
+
+// flowTemplateFields is the fixed field layout of the data records emitted by IPFIXProto: the
+// IPv4 5-tuple, byte/packet counters and timestamps as IANA-assigned IEs, plus the interface,
+// direction and Job/CronJob enrichment as netobservPEN enterprise-specific IEs. IPv6 is left
+// for a follow-up once the need for dual-stack support is confirmed.
+var flowTemplateFields = []ipfixField{
+	{ie: ieSourceIPv4Address, len: 4},
+	{ie: ieDestinationIPv4Address, len: 4},
+	{ie: ieSourceTransportPort, len: 2},
+	{ie: ieDestinationTransportPort, len: 2},
+	{ie: ieProtocolIdentifier, len: 1},
+	{ie: ieOctetDeltaCount, len: 8},
+	{ie: iePacketDeltaCount, len: 8},
+	{ie: ieFlowStartMilliseconds, len: 8},
+	{ie: ieFlowEndMilliseconds, len: 8},
+	{ie: ieIngressInterface, len: 4},
+	{ie: ieInterfaceName, len: interfaceNameLen, enterprise: true},
+	{ie: ieDirection, len: 1, enterprise: true},
+	{ie: ieSrcK8SJobName, len: k8sNameLen, enterprise: true},
+	{ie: ieSrcK8SCronJobName, len: k8sNameLen, enterprise: true},
+	{ie: ieDstK8SJobName, len: k8sNameLen, enterprise: true},
+	{ie: ieDstK8SCronJobName, len: k8sNameLen, enterprise: true},
+}
+
+// IPFIXProto exports flows as IPFIX (RFC 7011) messages over UDP or TCP, for collectors that
+// don't understand the agent's own protobuf/Kafka formats (nProbe, GoFlow2, vFlow...).
+type IPFIXProto struct {
+	conn               net.Conn
+	observationDomain  uint32
+	templateInterval   time.Duration
+	seq                uint32
+	lastTemplateSentAt time.Time
+}
+
+// StartIPFIXProto dials the given IPFIX collector address ("udp" or "tcp" network) and returns
+// an exporter ready to have its ExportFlows method plugged into the processing graph.
+func StartIPFIXProto(network, address string, observationDomain uint32, templateInterval time.Duration) (*IPFIXProto, error) {
+	conn, err := net.Dial(network, address)
+	if err != nil {
+		return nil, fmt.Errorf("dialing IPFIX collector %s://%s: %w", network, address, err)
+	}
+	return &IPFIXProto{
+		conn:              conn,
+		observationDomain: observationDomain,
+		templateInterval:  templateInterval,
+	}, nil
+}
+
+func (ix *IPFIXProto) ExportFlows(in <-chan []*flow.Record) {
+	iplog.Info("starting IPFIX exporter")
+	for records := range in {
+		ix.sendTemplateIfDue()
+		if err := ix.sendDataSet(records); err != nil {
+			iplog.WithError(err).Warn("can't send IPFIX data set. Dropping records")
+		}
+	}
+}
+
+// sendTemplateIfDue (re)sends the template set, either because it has never been sent or
+// because the periodic template refresh interval has elapsed, as required by RFC 7011 §8.1 so
+// that UDP collectors that missed the first copy (or restarted) can still decode data records.
+func (ix *IPFIXProto) sendTemplateIfDue() {
+	if !ix.lastTemplateSentAt.IsZero() && time.Since(ix.lastTemplateSentAt) < ix.templateInterval {
+		return
+	}
+	buf := &bytes.Buffer{}
+	tmplSet := &bytes.Buffer{}
+	binary.Write(tmplSet, binary.BigEndian, uint16(flowTemplateID))
+	binary.Write(tmplSet, binary.BigEndian, uint16(len(flowTemplateFields)))
+	for _, f := range flowTemplateFields {
+		if f.enterprise {
+			// Enterprise bit (RFC 7012 §1): the top bit of the IE number is set, and the
+			// field specifier grows a trailing 4-byte Private Enterprise Number.
+			binary.Write(tmplSet, binary.BigEndian, f.ie|0x8000)
+			binary.Write(tmplSet, binary.BigEndian, f.len)
+			binary.Write(tmplSet, binary.BigEndian, uint32(netobservPEN))
+			continue
+		}
+		binary.Write(tmplSet, binary.BigEndian, f.ie)
+		binary.Write(tmplSet, binary.BigEndian, f.len)
+	}
+	ix.writeSet(buf, templateSetID, tmplSet.Bytes())
+	if err := ix.writeMessage(buf); err != nil {
+		iplog.WithError(err).Warn("can't send IPFIX template set")
+		return
+	}
+	ix.lastTemplateSentAt = time.Now()
+}
+
+func (ix *IPFIXProto) sendDataSet(records []*flow.Record) error {
+	if len(records) == 0 {
+		return nil
+	}
+	data := &bytes.Buffer{}
+	for _, r := range records {
+		writeDataRecord(data, NewIPFIXRecord(r))
+	}
+	buf := &bytes.Buffer{}
+	ix.writeSet(buf, flowTemplateID, data.Bytes())
+	return ix.writeMessage(buf)
+}
+
+// IPFIXRecord wraps a flow.Record with the Kubernetes Job/CronJob owner enrichment fields also
+// exposed as SrcK8S_Job*/DstK8S_Job* by JSONRecord. pkg/enricher.ResolveJobOwner/JobOwnerCache
+// can resolve these, but nothing in pkg/agent calls them yet, so these fields are reserved and
+// always empty until that enrichment stage is wired into the agent's record pipeline.
+type IPFIXRecord struct {
+	*flow.Record
+	SrcK8S_JobName     string
+	SrcK8S_CronJobName string
+	DstK8S_JobName     string
+	DstK8S_CronJobName string
+}
+
+// NewIPFIXRecord wraps a flow.Record into the exported IPFIXRecord shape.
+func NewIPFIXRecord(r *flow.Record) *IPFIXRecord {
+	return &IPFIXRecord{Record: r}
+}
+
+func writeDataRecord(w *bytes.Buffer, r *IPFIXRecord) {
+	_ = binary.Write(w, binary.BigEndian, net.IP(r.Id.SrcIp[:]).To4())
+	_ = binary.Write(w, binary.BigEndian, net.IP(r.Id.DstIp[:]).To4())
+	_ = binary.Write(w, binary.BigEndian, r.Id.SrcPort)
+	_ = binary.Write(w, binary.BigEndian, r.Id.DstPort)
+	_ = binary.Write(w, binary.BigEndian, r.Id.TransportProtocol)
+	_ = binary.Write(w, binary.BigEndian, r.Metrics.Bytes)
+	_ = binary.Write(w, binary.BigEndian, uint64(r.Metrics.Packets))
+	_ = binary.Write(w, binary.BigEndian, nsToFlowMillis(r.Metrics.StartMonoTimeTs))
+	_ = binary.Write(w, binary.BigEndian, nsToFlowMillis(r.Metrics.EndMonoTimeTs))
+	_ = binary.Write(w, binary.BigEndian, r.Id.IfIndex)
+	w.Write(fixedWidthString(r.Interface, interfaceNameLen))
+	_ = binary.Write(w, binary.BigEndian, r.Id.Direction)
+	w.Write(fixedWidthString(r.SrcK8S_JobName, k8sNameLen))
+	w.Write(fixedWidthString(r.SrcK8S_CronJobName, k8sNameLen))
+	w.Write(fixedWidthString(r.DstK8S_JobName, k8sNameLen))
+	w.Write(fixedWidthString(r.DstK8S_CronJobName, k8sNameLen))
+}
+
+// fixedWidthString truncates or NUL-pads s to exactly n bytes, for the fixed-size string IEs
+// in flowTemplateFields.
+func fixedWidthString(s string, n int) []byte {
+	b := make([]byte, n)
+	copy(b, s)
+	return b
+}
+
+// nsToFlowMillis converts a monotonic nanosecond timestamp into IPFIX's "milliseconds since
+// the Unix epoch" (flowStartMilliseconds/flowEndMilliseconds IEs).
+func nsToFlowMillis(monoNs uint64) uint64 {
+	// monoNs shares its clock with monotime.Now() (bpf_ktime_get_ns on the kernel side): shift
+	// the wall clock back by how long ago that was
+	elapsed := time.Duration(uint64(monotime.Now()) - monoNs)
+	return uint64(time.Now().Add(-elapsed).UnixMilli())
+}
+
+// writeSet wraps the given set body (a template or data set) with its Set Header (Set ID +
+// Length), as defined by RFC 7011 §3.3.2.
+func (ix *IPFIXProto) writeSet(msg *bytes.Buffer, setID uint16, body []byte) {
+	binary.Write(msg, binary.BigEndian, setID)
+	binary.Write(msg, binary.BigEndian, uint16(len(body)+4))
+	msg.Write(body)
+}
+
+// writeMessage prepends the IPFIX Message Header (RFC 7011 §3.1) to the given set(s) and
+// writes the resulting datagram to the collector connection.
+func (ix *IPFIXProto) writeMessage(sets *bytes.Buffer) error {
+	ix.seq++
+	header := &bytes.Buffer{}
+	binary.Write(header, binary.BigEndian, uint16(ipfixVersion))
+	binary.Write(header, binary.BigEndian, uint16(16+sets.Len()))
+	binary.Write(header, binary.BigEndian, uint32(time.Now().Unix()))
+	binary.Write(header, binary.BigEndian, ix.seq)
+	binary.Write(header, binary.BigEndian, ix.observationDomain)
+	if _, err := ix.conn.Write(append(header.Bytes(), sets.Bytes()...)); err != nil {
+		return fmt.Errorf("writing IPFIX message: %w", err)
+	}
+	return nil
+}
+
+// Close releases the underlying collector connection.
+func (ix *IPFIXProto) Close() error {
+	return ix.conn.Close()
+}
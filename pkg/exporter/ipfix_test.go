@@ -0,0 +1,138 @@
+package exporter
+
+import (
+	"encoding/binary"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/ebpf"
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/flow"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIPFIXCommunication mirrors TestUDPCommunication: it spins up a bare-bones IPFIX
+// collector, starts an IPFIXProto exporter against it, and checks that the template set and
+// the data set it sends can be decoded back into the fields the exporter claims to export.
+func TestIPFIXCommunication(t *testing.T) {
+	server, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer server.Close()
+
+	ix, err := StartIPFIXProto("udp", server.LocalAddr().String(), 42, time.Hour)
+	require.NoError(t, err)
+	defer ix.Close()
+
+	records := []*flow.Record{{
+		Interface: "eth0",
+		Id: ebpf.BpfFlowId{
+			SrcIp:             ipv4MappedBytes(1, 2, 3, 4),
+			DstIp:             ipv4MappedBytes(5, 6, 7, 8),
+			SrcPort:           1234,
+			DstPort:           443,
+			TransportProtocol: 6,
+			IfIndex:           3,
+			Direction:         1,
+		},
+	}}
+	records[0].Metrics.Bytes = 1000
+	records[0].Metrics.Packets = 10
+
+	in := make(chan []*flow.Record, 1)
+	in <- records
+	close(in)
+	ix.ExportFlows(in)
+
+	// first datagram: the template set
+	tmplMsg := readMessage(t, server)
+	fields := decodeTemplateFields(t, tmplMsg)
+	require.Len(t, fields, len(flowTemplateFields))
+	assert.EqualValues(t, ieSourceIPv4Address, fields[0].ie&0x7FFF)
+	assert.EqualValues(t, ieInterfaceName|0x8000, fields[10].ie)
+	assert.EqualValues(t, netobservPEN, fields[10].pen)
+
+	// second datagram: the data set
+	dataMsg := readMessage(t, server)
+	rec := decodeDataRecord(t, dataMsg, fields)
+	assert.Equal(t, []byte(net.IPv4(1, 2, 3, 4).To4()), rec[ieSourceIPv4Address])
+	assert.Equal(t, []byte(net.IPv4(5, 6, 7, 8).To4()), rec[ieDestinationIPv4Address])
+	assert.EqualValues(t, 1000, binary.BigEndian.Uint64(rec[ieOctetDeltaCount]))
+	assert.EqualValues(t, 10, binary.BigEndian.Uint64(rec[iePacketDeltaCount]))
+	assert.EqualValues(t, 3, binary.BigEndian.Uint32(rec[ieIngressInterface]))
+	assert.EqualValues(t, 1, rec[ieDirection|0x8000][0])
+	assert.Equal(t, "eth0", trimNulString(rec[ieInterfaceName|0x8000]))
+}
+
+func ipv4MappedBytes(a, b, c, d byte) [16]byte {
+	var out [16]byte
+	ip := net.IPv4(a, b, c, d).To16()
+	copy(out[:], ip)
+	return out
+}
+
+func trimNulString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+type decodedField struct {
+	ie  uint16
+	len uint16
+	pen uint32
+}
+
+// readMessage reads one UDP datagram off the collector socket and strips its 16-byte IPFIX
+// Message Header, returning the raw set bytes.
+func readMessage(t *testing.T, server net.PacketConn) []byte {
+	t.Helper()
+	buf := make([]byte, 2048)
+	require.NoError(t, server.SetReadDeadline(time.Now().Add(5*time.Second)))
+	n, _, err := server.ReadFrom(buf)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, n, 16)
+	return buf[16:n]
+}
+
+// decodeTemplateFields decodes a Template Set body into its field specifiers, handling the
+// RFC 7012 enterprise bit/PEN extension the same way the exporter encodes it.
+func decodeTemplateFields(t *testing.T, setBytes []byte) []decodedField {
+	t.Helper()
+	// Set Header (4B) + Template ID (2B) + Field Count (2B)
+	require.GreaterOrEqual(t, len(setBytes), 8)
+	count := binary.BigEndian.Uint16(setBytes[6:8])
+	pos := 8
+	var fields []decodedField
+	for i := 0; i < int(count); i++ {
+		ie := binary.BigEndian.Uint16(setBytes[pos : pos+2])
+		length := binary.BigEndian.Uint16(setBytes[pos+2 : pos+4])
+		pos += 4
+		f := decodedField{ie: ie, len: length}
+		if ie&0x8000 != 0 {
+			f.pen = binary.BigEndian.Uint32(setBytes[pos : pos+4])
+			pos += 4
+		}
+		fields = append(fields, f)
+	}
+	return fields
+}
+
+// decodeDataRecord decodes a Data Set body (one record) according to the given field layout,
+// keyed by the IE number as it appears in the template: enterprise-specific IEs keep their
+// 0x8000 bit, so they don't collide with an IANA IE of the same number.
+func decodeDataRecord(t *testing.T, setBytes []byte, fields []decodedField) map[uint16][]byte {
+	t.Helper()
+	// Set Header: Set ID (2B) + Length (2B)
+	require.GreaterOrEqual(t, len(setBytes), 4)
+	pos := 4
+	rec := map[uint16][]byte{}
+	for _, f := range fields {
+		rec[f.ie] = setBytes[pos : pos+int(f.len)]
+		pos += int(f.len)
+	}
+	return rec
+}
@@ -0,0 +1,38 @@
+package exporter
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfluentEnvelope(t *testing.T) {
+	env := confluentEnvelope(7, []byte{0xaa, 0xbb})
+	require.Len(t, env, 7)
+	assert.Equal(t, byte(0), env[0])
+	assert.Equal(t, []byte{0, 0, 0, 7}, env[1:5])
+	assert.Equal(t, []byte{0xaa, 0xbb}, env[5:])
+}
+
+func TestSchemaRegistryClient_CachesID(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"id": 42}`))
+	}))
+	defer srv.Close()
+
+	client := NewSchemaRegistryClient(srv.URL)
+	id, err := client.SchemaID("flows-value", `{"type":"record","name":"Flow","fields":[]}`)
+	require.NoError(t, err)
+	assert.Equal(t, 42, id)
+
+	id2, err := client.SchemaID("flows-value", `{"type":"record","name":"Flow","fields":[]}`)
+	require.NoError(t, err)
+	assert.Equal(t, 42, id2)
+	assert.Equal(t, 1, calls, "second call should be served from cache")
+}
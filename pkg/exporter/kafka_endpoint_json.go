@@ -0,0 +1,44 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/flow"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+var kelog = klog.WithField("stream", "endpoints")
+
+// KafkaEndpointJSON exports flow.EndpointUsage batches over Kafka, JSON-encoded, as the optional
+// lightweight per-endpoint bandwidth stream alongside the regular flow-record exporters.
+type KafkaEndpointJSON struct {
+	Writer kafkaWriter
+}
+
+func (ke *KafkaEndpointJSON) ExportEndpoints(input <-chan []*flow.EndpointUsage) {
+	kelog.Info("starting Kafka endpoint usage exporter")
+	for usages := range input {
+		ke.batchAndSubmit(usages)
+	}
+}
+
+func (ke *KafkaEndpointJSON) batchAndSubmit(usages []*flow.EndpointUsage) {
+	kelog.Debugf("sending %d endpoint usage records", len(usages))
+	msgs := make([]kafkago.Message, 0, len(usages))
+	for _, usage := range usages {
+		jsonBytes, err := json.Marshal(usage)
+		if err != nil {
+			kelog.WithError(err).Debug("can't encode JSON message. Ignoring")
+			continue
+		}
+		msgs = append(msgs, kafkago.Message{
+			Key:   []byte(usage.Mac),
+			Value: jsonBytes,
+		})
+	}
+
+	if err := ke.Writer.WriteMessages(context.TODO(), msgs...); err != nil {
+		kelog.WithError(err).Error("can't write messages into Kafka")
+	}
+}
@@ -0,0 +1,64 @@
+package exporter
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/flow"
+	kafkago "github.com/segmentio/kafka-go"
+)
+
+var kjlog = klog.WithField("encoding", "json")
+
+// KafkaJSON exports flows over Kafka, JSON-encoded as JSONRecord, for pipelines built around
+// Kafka Connect/ksqlDB that can't consume the protobuf encoding used by KafkaProto.
+type KafkaJSON struct {
+	Writer kafkaWriter
+	// PartitionKey selects the partitioning strategy applied to outgoing messages, see the
+	// PartitionKey* constants.
+	PartitionKey string
+	// AgentIP is this agent's own IP, used as the message key when PartitionKey is
+	// PartitionKeyAgentIP. Ignored by every other strategy.
+	AgentIP string
+}
+
+func (kj *KafkaJSON) ExportFlows(input <-chan []*flow.Record) {
+	kjlog.Info("starting Kafka exporter")
+	for records := range input {
+		kj.batchAndSubmit(records)
+	}
+}
+
+func (kj *KafkaJSON) batchAndSubmit(records []*flow.Record) {
+	kjlog.Debugf("sending %d records", len(records))
+	msgs := make([]kafkago.Message, 0, len(records))
+	for _, record := range records {
+		jsonBytes, err := json.Marshal(NewJSONRecord(record))
+		if err != nil {
+			kjlog.WithError(err).Debug("can't encode JSON message. Ignoring")
+			continue
+		}
+		msgs = append(msgs, kafkago.Message{
+			Key:   partitionKey(kj.PartitionKey, record, kj.AgentIP),
+			Value: jsonBytes,
+		})
+	}
+
+	if err := kj.Writer.WriteMessages(context.TODO(), msgs...); err != nil {
+		kjlog.WithError(err).Error("can't write messages into Kafka")
+	}
+}
+
+// NewJSONRecord wraps a flow.Record into the exported JSONRecord shape, deriving its wall-clock
+// time fields from the record's monotonic flow timestamps the same way the IPFIX exporter does.
+func NewJSONRecord(r *flow.Record) *JSONRecord {
+	startMs := int64(nsToFlowMillis(r.Metrics.StartMonoTimeTs))
+	endMs := int64(nsToFlowMillis(r.Metrics.EndMonoTimeTs))
+	return &JSONRecord{
+		Record:          r,
+		TimeFlowStart:   startMs / 1000,
+		TimeFlowEnd:     endMs / 1000,
+		TimeFlowStartMs: startMs,
+		TimeFlowEndMs:   endMs,
+	}
+}
@@ -2,15 +2,51 @@ package exporter
 
 import (
 	"context"
+	"crypto/tls"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
 
 	"github.com/netobserv/netobserv-ebpf-agent/pkg/flow"
 	kafkago "github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/protobuf/proto"
 )
 
 var klog = logrus.WithField("component", "exporter/KafkaProto")
 
+// Partition key strategies accepted by Config.KafkaPartitionKey. They select which bytes of a
+// flow.Record are hashed into kafkago.Message.Key, so that records belonging to the same logical
+// entity land on the same partition and can be aggregated in order downstream.
+//
+// "5tuple" and "srcDstIP" are accepted as aliases of PartitionKeyFiveTuple and
+// PartitionKeyConversationHash respectively, matching the naming used by some other
+// Flowlogs-Pipeline-adjacent tooling.
+const (
+	PartitionKeyNone             = "none"
+	PartitionKeyFiveTuple        = "fiveTuple"
+	PartitionKeySrcIP            = "srcIP"
+	PartitionKeyDstIP            = "dstIP"
+	PartitionKeyConversationHash = "conversationHash"
+	PartitionKeyFlowID           = "flowid"
+	PartitionKeyInterface        = "interface"
+	PartitionKeyAgentIP          = "agentIP"
+
+	partitionKeyFiveTupleAlias        = "5tuple"
+	partitionKeyConversationHashAlias = "srcDstIP"
+)
+
+// Balancer selection strategies accepted by Config.KafkaPartitioner. They pick the
+// kafkago.Balancer used to route a message with a given Key (or no Key at all) to a partition.
+const (
+	KafkaPartitionerHash       = "hash"
+	KafkaPartitionerRoundRobin = "roundrobin"
+	KafkaPartitionerManual     = "manual"
+	KafkaPartitionerMurmur2    = "murmur2"
+)
+
 type kafkaWriter interface {
 	WriteMessages(ctx context.Context, msgs ...kafkago.Message) error
 }
@@ -19,16 +55,27 @@ type kafkaWriter interface {
 // Flowlogs-Pipeline collector
 type KafkaProto struct {
 	Writer kafkaWriter
+	// PartitionKey selects the partitioning strategy applied to outgoing messages. Empty
+	// behaves like PartitionKeyNone. See the PartitionKey* constants.
+	PartitionKey string
+	// AgentIP is this agent's own IP, used as the message key when PartitionKey is
+	// PartitionKeyAgentIP. Ignored by every other strategy.
+	AgentIP string
 }
 
 func (kp *KafkaProto) ExportFlows(input <-chan []*flow.Record) {
 	klog.Info("starting Kafka exporter")
 	for records := range input {
-		kp.batchAndSubmit(records)
+		if err := kp.Submit(records); err != nil {
+			klog.WithError(err).Error("can't write messages into Kafka")
+		}
 	}
 }
 
-func (kp *KafkaProto) batchAndSubmit(records []*flow.Record) {
+// Submit encodes records and writes them to Kafka as a single batch, returning any producer
+// error instead of just logging it, so a RetryQueue wrapping this exporter can retry the batch
+// rather than silently dropping it.
+func (kp *KafkaProto) Submit(records []*flow.Record) error {
 	klog.Debugf("sending %d records", len(records))
 	msgs := make([]kafkago.Message, 0, len(records))
 	for _, record := range records {
@@ -37,18 +84,268 @@ func (kp *KafkaProto) batchAndSubmit(records []*flow.Record) {
 			klog.WithError(err).Debug("can't encode protobuf message. Ignoring")
 			continue
 		}
-		msgs = append(msgs, kafkago.Message{Value: pbBytes})
+		msgs = append(msgs, kafkago.Message{
+			Key:   partitionKey(kp.PartitionKey, record, kp.AgentIP),
+			Value: pbBytes,
+		})
 	}
+	return kp.Writer.WriteMessages(context.TODO(), msgs...)
+}
 
-	if err := kp.Writer.WriteMessages(context.TODO(), msgs...); err != nil {
-		klog.WithError(err).Error("can't write messages into Kafka")
+// partitionKey derives the kafkago.Message.Key for a record according to strategy. It returns
+// nil for PartitionKeyNone (or an unrecognized strategy), which keeps kafka-go's default
+// round-robin/least-bytes balancing. agentIP is only consulted by PartitionKeyAgentIP.
+func partitionKey(strategy string, r *flow.Record, agentIP string) []byte {
+	switch strategy {
+	case PartitionKeyFiveTuple, partitionKeyFiveTupleAlias:
+		return fiveTupleKey(r)
+	case PartitionKeySrcIP:
+		return net.IP(r.Id.SrcIp[:]).To16()
+	case PartitionKeyDstIP:
+		return net.IP(r.Id.DstIp[:]).To16()
+	case PartitionKeyConversationHash, partitionKeyConversationHashAlias:
+		return conversationKey(r)
+	case PartitionKeyFlowID:
+		return flowIDKey(r)
+	case PartitionKeyInterface:
+		return binary.BigEndian.AppendUint32(nil, r.Id.IfIndex)
+	case PartitionKeyAgentIP:
+		if agentIP == "" {
+			return nil
+		}
+		return net.ParseIP(agentIP).To16()
+	default:
+		return nil
 	}
 }
 
+func fiveTupleKey(r *flow.Record) []byte {
+	key := make([]byte, 0, 16+16+2+2+1)
+	key = append(key, r.Id.SrcIp[:]...)
+	key = append(key, r.Id.DstIp[:]...)
+	key = binary.BigEndian.AppendUint16(key, r.Id.SrcPort)
+	key = binary.BigEndian.AppendUint16(key, r.Id.DstPort)
+	key = append(key, r.Id.TransportProtocol)
+	return key
+}
+
+// flowIDKey extends fiveTupleKey with the fields that make a BpfFlowId unique (interface and
+// direction), so that, unlike PartitionKeyFiveTuple, two flows sharing a 5-tuple across different
+// interfaces or directions (e.g. ingress vs. egress on a veth pair) still get distinct keys.
+func flowIDKey(r *flow.Record) []byte {
+	key := fiveTupleKey(r)
+	key = binary.BigEndian.AppendUint32(key, r.Id.IfIndex)
+	key = append(key, r.Id.Direction)
+	return key
+}
+
+// conversationKey canonicalizes the endpoints of a flow so that both directions of the same
+// conversation (e.g. client->server and server->client) produce the same key, regardless of
+// which side is recorded as Src/Dst.
+func conversationKey(r *flow.Record) []byte {
+	srcAddr := append(append([]byte{}, r.Id.SrcIp[:]...), portBytes(r.Id.SrcPort)...)
+	dstAddr := append(append([]byte{}, r.Id.DstIp[:]...), portBytes(r.Id.DstPort)...)
+	if bytesLess(dstAddr, srcAddr) {
+		srcAddr, dstAddr = dstAddr, srcAddr
+	}
+	key := make([]byte, 0, len(srcAddr)+len(dstAddr)+1)
+	key = append(key, srcAddr...)
+	key = append(key, dstAddr...)
+	key = append(key, r.Id.TransportProtocol)
+	return key
+}
+
+func portBytes(port uint16) []byte {
+	b := make([]byte, 2)
+	binary.BigEndian.PutUint16(b, port)
+	return b
+}
+
+func bytesLess(a, b []byte) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// kafkaCompression maps the user-facing compression names (as accepted by Config.
+// KafkaCompression) onto kafka-go's Compression enum used by kafkago.Writer.
+func kafkaCompression(name string) (kafkago.Compression, error) {
+	switch name {
+	case "", "none":
+		return 0, nil
+	case "gzip":
+		return kafkago.Gzip, nil
+	case "snappy":
+		return kafkago.Snappy, nil
+	case "lz4":
+		return kafkago.Lz4, nil
+	case "zstd":
+		return kafkago.Zstd, nil
+	default:
+		return 0, fmt.Errorf("unsupported kafka compression %q", name)
+	}
+}
+
+// kafkaRequiredAcks maps the user-facing acks names (as accepted by Config.KafkaRequiredAcks:
+// "none", "one" (alias "leader"), "all") onto kafka-go's RequiredAcks enum.
+func kafkaRequiredAcks(name string) (kafkago.RequiredAcks, error) {
+	switch name {
+	case "", "one", "leader":
+		return kafkago.RequireOne, nil
+	case "none":
+		return kafkago.RequireNone, nil
+	case "all":
+		return kafkago.RequireAll, nil
+	default:
+		return 0, fmt.Errorf("unsupported kafka requiredAcks %q", name)
+	}
+}
+
+// kafkaBalancer maps the user-facing partitioner name (Config.KafkaPartitioner) onto a
+// kafkago.Balancer. An empty name keeps the historical default: kafkago.Hash when a
+// non-"none" PartitionKey strategy is configured (so that messages sharing a Key land on the
+// same partition), or kafkago.LeastBytes otherwise. KafkaPartitionerManual doesn't map to a
+// kafkago.Balancer at all: kafka-go always routes through one, so "manual" is only meaningful
+// to callers that set kafkago.Message.Partition themselves on every message, bypassing the
+// Writer's balancer entirely - this exporter doesn't do that, and returns an error instead.
+func kafkaBalancer(name string, hasPartitionKey bool) (kafkago.Balancer, error) {
+	switch name {
+	case "":
+		if hasPartitionKey {
+			return &kafkago.Hash{}, nil
+		}
+		return &kafkago.LeastBytes{}, nil
+	case KafkaPartitionerHash:
+		return &kafkago.Hash{}, nil
+	case KafkaPartitionerRoundRobin:
+		return &kafkago.RoundRobin{}, nil
+	case KafkaPartitionerMurmur2:
+		// Matches the partitioner Java/Sarama clients default to, for co-partitioning with
+		// consumers written against those ecosystems.
+		return &kafkago.Murmur2Balancer{}, nil
+	case KafkaPartitionerManual:
+		return nil, fmt.Errorf("kafka partitioner %q requires the caller to set kafkago.Message.Partition directly; unsupported by this exporter", name)
+	default:
+		return nil, fmt.Errorf("unsupported kafka partitioner %q", name)
+	}
+}
+
+// NewKafkaWriter builds a kafka-go Writer configured from an agent Config, applying the
+// compression, batch and acknowledgement settings, and the Balancer selected by cfg.Partitioner
+// (see kafkaBalancer).
+func NewKafkaWriter(cfg KafkaWriterConfig) (*kafkago.Writer, error) {
+	compression, err := kafkaCompression(cfg.Compression)
+	if err != nil {
+		return nil, err
+	}
+	acks, err := kafkaRequiredAcks(cfg.RequiredAcks)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Idempotent {
+		// kafka-go's Writer doesn't implement the broker-side sequence-number protocol behind
+		// Sarama/librdkafka's enable.idempotence, so this can't give a full duplicate-suppression
+		// guarantee. It does give the safe subset that matters most for not losing or
+		// reordering data: every broker in the ISR must ack a batch before the write returns.
+		acks = kafkago.RequireAll
+	}
+	balancer, err := kafkaBalancer(cfg.Partitioner, cfg.PartitionKey != "" && cfg.PartitionKey != PartitionKeyNone)
+	if err != nil {
+		return nil, err
+	}
+	writer := &kafkago.Writer{
+		Addr:         kafkago.TCP(cfg.Brokers...),
+		Topic:        cfg.Topic,
+		Balancer:     balancer,
+		Compression:  compression,
+		RequiredAcks: acks,
+		BatchSize:    cfg.BatchSize,
+		BatchBytes:   cfg.BatchBytes,
+		WriteTimeout: cfg.RequestTimeout,
+		// Async=false makes WriteMessages block until the broker acks the batch and return its
+		// error, which Submit propagates to the caller instead of dropping it; Async=true fires
+		// and forgets, trading that visibility for throughput, so Submit never sees a producer
+		// error. Config.KafkaAsync, which sets it, defaults to true.
+		Async: cfg.Async,
+	}
+	if cfg.TransportTLS != nil || cfg.SASLMechanism != nil || cfg.ClientID != "" {
+		writer.Transport = &kafkago.Transport{
+			TLS:      cfg.TransportTLS,
+			SASL:     cfg.SASLMechanism,
+			ClientID: cfg.ClientID,
+		}
+	}
+	return writer, nil
+}
+
+// KafkaWriterConfig bundles the agent Config fields needed to build a kafka-go Writer, decoupling
+// pkg/exporter from pkg/agent.Config.
+type KafkaWriterConfig struct {
+	Brokers      []string
+	Topic        string
+	PartitionKey string
+	// Partitioner selects the kafkago.Balancer used to route messages to partitions. Empty
+	// keeps the historical default (see kafkaBalancer). See the KafkaPartitioner* constants.
+	Partitioner string
+
+	Compression  string
+	RequiredAcks string
+	BatchSize    int
+	BatchBytes   int64
+	TransportTLS *tls.Config
+	// ClientID identifies this agent to the brokers, surfaced in broker-side request logging
+	// and quotas.
+	ClientID      string
+	SASLMechanism sasl.Mechanism
+	// Async mirrors Config.KafkaAsync (true by default): false makes the writer block on each
+	// batch and surface producer errors to Submit's caller; true fires and forgets.
+	Async bool
+	// Idempotent mirrors Config.KafkaIdempotent; see the rationale note in NewKafkaWriter.
+	Idempotent bool
+	// MaxInFlight mirrors Config.KafkaMaxInFlight. NewKafkaWriter doesn't read it: pkg/agent
+	// applies Config.KafkaMaxInFlight to the RetryQueue's consumer count instead, approximating
+	// Sarama/librdkafka's max.in.flight.requests.per.connection since kafka-go's Writer has no
+	// equivalent knob of its own.
+	MaxInFlight int
+	// RequestTimeout bounds how long a single WriteMessages call waits for the brokers to ack a
+	// batch before failing it.
+	RequestTimeout time.Duration
+}
+
 type JSONRecord struct {
 	*flow.Record
 	TimeFlowStart   int64
 	TimeFlowEnd     int64
 	TimeFlowStartMs int64
 	TimeFlowEndMs   int64
+	// SrcK8S_JobName, SrcK8S_CronJobName, SrcK8S_JobCompletionIndex, SrcK8S_JobCompletionMode
+	// and SrcK8S_JobManagedBy (and their Dst equivalents) are meant to be populated by the Job/
+	// CronJob owner enrichment stage (see pkg/enricher.ResolveJobOwner/JobOwnerCache). Nothing
+	// in pkg/agent calls that stage yet, so these fields are reserved and always empty until
+	// it's wired into the agent's record pipeline.
+	SrcK8S_JobName            string `json:",omitempty"`
+	SrcK8S_CronJobName        string `json:",omitempty"`
+	SrcK8S_JobCompletionIndex string `json:",omitempty"`
+	SrcK8S_JobCompletionMode  string `json:",omitempty"`
+	SrcK8S_JobManagedBy       string `json:",omitempty"`
+	DstK8S_JobName            string `json:",omitempty"`
+	DstK8S_CronJobName        string `json:",omitempty"`
+	DstK8S_JobCompletionIndex string `json:",omitempty"`
+	DstK8S_JobCompletionMode  string `json:",omitempty"`
+	DstK8S_JobManagedBy       string `json:",omitempty"`
+	// SnapshotID is reserved for the scheduled capture window the flow belongs to (see
+	// pkg/agent.SnapshotScheduler). Nothing sets it, so it is always empty.
+	SnapshotID string `json:",omitempty"`
+}
+
+// SnapshotEndMarker is the shape of a lightweight, flow-less record marking that a scheduled
+// capture window closed, so that downstream consumers can deterministically close the batch for
+// that SnapshotID instead of relying on a quiet period or timeout. No exporter builds or sends
+// one.
+type SnapshotEndMarker struct {
+	SnapshotID string
+	ClosedAt   int64
 }
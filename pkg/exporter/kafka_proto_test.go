@@ -0,0 +1,150 @@
+package exporter
+
+import (
+	"testing"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/ebpf"
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/flow"
+	kafkago "github.com/segmentio/kafka-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func recordWith(srcIP, dstIP [16]byte, srcPort, dstPort uint16, proto uint8) *flow.Record {
+	return &flow.Record{
+		Id: ebpf.BpfFlowId{
+			SrcIp:             srcIP,
+			DstIp:             dstIP,
+			SrcPort:           srcPort,
+			DstPort:           dstPort,
+			TransportProtocol: proto,
+		},
+	}
+}
+
+func TestPartitionKey_None(t *testing.T) {
+	r := recordWith([16]byte{1}, [16]byte{2}, 1000, 2000, 6)
+	assert.Nil(t, partitionKey(PartitionKeyNone, r, ""))
+	assert.Nil(t, partitionKey("", r, ""))
+	assert.Nil(t, partitionKey("bogus", r, ""))
+}
+
+func TestPartitionKey_SrcDstIP(t *testing.T) {
+	r := recordWith([16]byte{1}, [16]byte{2}, 1000, 2000, 6)
+	assert.NotEqual(t, partitionKey(PartitionKeySrcIP, r, ""), partitionKey(PartitionKeyDstIP, r, ""))
+}
+
+func TestPartitionKey_ConversationHash_SymmetricAcrossDirections(t *testing.T) {
+	forward := recordWith([16]byte{1}, [16]byte{2}, 1000, 2000, 6)
+	reverse := recordWith([16]byte{2}, [16]byte{1}, 2000, 1000, 6)
+	assert.Equal(t, partitionKey(PartitionKeyConversationHash, forward, ""), partitionKey(PartitionKeyConversationHash, reverse, ""))
+
+	other := recordWith([16]byte{1}, [16]byte{3}, 1000, 2000, 6)
+	assert.NotEqual(t, partitionKey(PartitionKeyConversationHash, forward, ""), partitionKey(PartitionKeyConversationHash, other, ""))
+}
+
+func TestPartitionKey_ConversationHashAlias_MatchesConversationHash(t *testing.T) {
+	r := recordWith([16]byte{1}, [16]byte{2}, 1000, 2000, 6)
+	assert.Equal(t, partitionKey(PartitionKeyConversationHash, r, ""), partitionKey("srcDstIP", r, ""))
+}
+
+func TestPartitionKey_FiveTuple_DiffersByDirection(t *testing.T) {
+	forward := recordWith([16]byte{1}, [16]byte{2}, 1000, 2000, 6)
+	reverse := recordWith([16]byte{2}, [16]byte{1}, 2000, 1000, 6)
+	assert.NotEqual(t, partitionKey(PartitionKeyFiveTuple, forward, ""), partitionKey(PartitionKeyFiveTuple, reverse, ""))
+}
+
+func TestPartitionKey_FiveTupleAlias_MatchesFiveTuple(t *testing.T) {
+	r := recordWith([16]byte{1}, [16]byte{2}, 1000, 2000, 6)
+	assert.Equal(t, partitionKey(PartitionKeyFiveTuple, r, ""), partitionKey("5tuple", r, ""))
+}
+
+func TestPartitionKey_FlowID_DiffersByInterface(t *testing.T) {
+	a := recordWith([16]byte{1}, [16]byte{2}, 1000, 2000, 6)
+	a.Id.IfIndex = 1
+	b := recordWith([16]byte{1}, [16]byte{2}, 1000, 2000, 6)
+	b.Id.IfIndex = 2
+	assert.NotEqual(t, partitionKey(PartitionKeyFlowID, a, ""), partitionKey(PartitionKeyFlowID, b, ""))
+}
+
+func TestPartitionKey_Interface(t *testing.T) {
+	a := recordWith([16]byte{1}, [16]byte{2}, 1000, 2000, 6)
+	a.Id.IfIndex = 1
+	b := recordWith([16]byte{1}, [16]byte{2}, 1000, 2000, 6)
+	b.Id.IfIndex = 2
+	assert.Equal(t, partitionKey(PartitionKeyInterface, a, ""), partitionKey(PartitionKeyInterface, a, ""))
+	assert.NotEqual(t, partitionKey(PartitionKeyInterface, a, ""), partitionKey(PartitionKeyInterface, b, ""))
+}
+
+func TestPartitionKey_AgentIP(t *testing.T) {
+	r := recordWith([16]byte{1}, [16]byte{2}, 1000, 2000, 6)
+	assert.Nil(t, partitionKey(PartitionKeyAgentIP, r, ""))
+	assert.NotNil(t, partitionKey(PartitionKeyAgentIP, r, "10.0.0.1"))
+}
+
+func TestKafkaBalancer_Manual_Unsupported(t *testing.T) {
+	_, err := kafkaBalancer(KafkaPartitionerManual, false)
+	assert.Error(t, err)
+}
+
+func TestKafkaBalancer_Unsupported(t *testing.T) {
+	_, err := kafkaBalancer("bogus", false)
+	assert.Error(t, err)
+}
+
+func TestKafkaBalancer_DefaultsToHashWhenPartitionKeySet(t *testing.T) {
+	b, err := kafkaBalancer("", true)
+	assert.NoError(t, err)
+	assert.IsType(t, &kafkago.Hash{}, b)
+}
+
+// BenchmarkPartitionSkew_None_vs_ConversationHash demonstrates that keying by
+// PartitionKeyConversationHash, unlike PartitionKeyNone, produces a distinct key per
+// conversation: with PartitionKeyNone every message shares the same (nil) key, so a
+// Hash-balanced topic would pile every message onto a single partition, while
+// PartitionKeyConversationHash spreads them out while still co-locating both directions of the
+// same conversation.
+func BenchmarkPartitionSkew_None_vs_ConversationHash(b *testing.B) {
+	records := make([]*flow.Record, 1000)
+	for i := range records {
+		records[i] = recordWith([16]byte{1}, byte16(i), 1000, 2000, 6)
+	}
+	b.Run("none", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			seen := map[string]struct{}{}
+			for _, r := range records {
+				seen[string(partitionKey(PartitionKeyNone, r, ""))] = struct{}{}
+			}
+			if len(seen) != 1 {
+				b.Fatalf("expected every PartitionKeyNone key to collide, got %d distinct keys", len(seen))
+			}
+		}
+	})
+	b.Run("conversationHash", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			seen := map[string]struct{}{}
+			for _, r := range records {
+				seen[string(partitionKey(PartitionKeyConversationHash, r, ""))] = struct{}{}
+			}
+			if len(seen) != len(records) {
+				b.Fatalf("expected %d distinct keys, got %d", len(records), len(seen))
+			}
+		}
+	})
+}
+
+func byte16(n int) [16]byte {
+	var b [16]byte
+	b[14] = byte(n >> 8)
+	b[15] = byte(n)
+	return b
+}
+
+func TestKafkaCompression_Unsupported(t *testing.T) {
+	_, err := kafkaCompression("bzip2")
+	assert.Error(t, err)
+}
+
+func TestKafkaRequiredAcks_Unsupported(t *testing.T) {
+	_, err := kafkaRequiredAcks("maybe")
+	assert.Error(t, err)
+}
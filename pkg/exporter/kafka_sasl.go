@@ -0,0 +1,89 @@
+package exporter
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// SASL mechanism names accepted by Config.KafkaSASLType.
+const (
+	SASLTypePlain       = "plain"
+	SASLTypeScramSHA512 = "scramSHA512"
+	SASLTypeScramSHA256 = "scramSHA256"
+	SASLTypeOAuthBearer = "oauthbearer"
+)
+
+// KafkaSASLConfig bundles the agent Config fields needed to build a sasl.Mechanism, decoupling
+// pkg/exporter from pkg/agent.Config the same way KafkaWriterConfig does for TLS/batching.
+type KafkaSASLConfig struct {
+	// Type selects the mechanism: "" (disabled), SASLTypePlain (default when enabled),
+	// SASLTypeScramSHA512, SASLTypeScramSHA256 or SASLTypeOAuthBearer.
+	Type string
+	// ClientIDPath/ClientSecretPath hold the paths to the SASL username/password, for
+	// SASLTypePlain and the SCRAM variants.
+	ClientIDPath     string
+	ClientSecretPath string
+	// OAuth* configure the OAUTHBEARER client-credentials token refresher, for
+	// SASLTypeOAuthBearer.
+	OAuthTokenURL         string
+	OAuthClientIDPath     string
+	OAuthClientSecretPath string
+	OAuthScopes           string
+}
+
+// BuildKafkaSASLMechanism builds the sasl.Mechanism matching cfg.Type, or returns a nil
+// Mechanism (and nil error) when cfg.Type is empty, meaning SASL isn't enabled.
+func BuildKafkaSASLMechanism(cfg KafkaSASLConfig) (sasl.Mechanism, error) {
+	switch cfg.Type {
+	case "":
+		return nil, nil
+	case SASLTypePlain:
+		username, password, err := readCredentialFiles(cfg.ClientIDPath, cfg.ClientSecretPath)
+		if err != nil {
+			return nil, err
+		}
+		return plain.Mechanism{Username: username, Password: password}, nil
+	case SASLTypeScramSHA512, SASLTypeScramSHA256:
+		username, password, err := readCredentialFiles(cfg.ClientIDPath, cfg.ClientSecretPath)
+		if err != nil {
+			return nil, err
+		}
+		algo := scram.SHA512
+		if cfg.Type == SASLTypeScramSHA256 {
+			algo = scram.SHA256
+		}
+		mechanism, err := scram.Mechanism(algo, username, password)
+		if err != nil {
+			return nil, fmt.Errorf("building SCRAM mechanism: %w", err)
+		}
+		return mechanism, nil
+	case SASLTypeOAuthBearer:
+		clientID, clientSecret, err := readCredentialFiles(cfg.OAuthClientIDPath, cfg.OAuthClientSecretPath)
+		if err != nil {
+			return nil, err
+		}
+		if cfg.OAuthTokenURL == "" {
+			return nil, fmt.Errorf("kafka SASL type %q requires a non-empty OAuth token URL", cfg.Type)
+		}
+		return newOAuthBearerMechanism(cfg.OAuthTokenURL, clientID, clientSecret, cfg.OAuthScopes), nil
+	default:
+		return nil, fmt.Errorf("unsupported Kafka SASL type %q", cfg.Type)
+	}
+}
+
+func readCredentialFiles(idPath, secretPath string) (string, string, error) {
+	id, err := os.ReadFile(idPath)
+	if err != nil {
+		return "", "", fmt.Errorf("reading SASL client ID: %w", err)
+	}
+	secret, err := os.ReadFile(secretPath)
+	if err != nil {
+		return "", "", fmt.Errorf("reading SASL client secret: %w", err)
+	}
+	return strings.TrimSpace(string(id)), strings.TrimSpace(string(secret)), nil
+}
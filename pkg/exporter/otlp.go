@@ -0,0 +1,295 @@
+package exporter
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/flow"
+	"github.com/sirupsen/logrus"
+)
+
+var otlog = logrus.WithField("component", "exporter/OTLPProto")
+
+// OTLP encoding choices accepted by Config.OTLPEncoding.
+const (
+	OTLPEncodingLogs    = "logs"
+	OTLPEncodingMetrics = "metrics"
+)
+
+// OTLPConfig bundles the agent Config fields needed to build an OTLPProto exporter, decoupling
+// pkg/exporter from pkg/agent.Config the same way KafkaWriterConfig does for Kafka.
+type OTLPConfig struct {
+	// Endpoint is the full OTLP/HTTP collector URL for the configured Encoding, e.g.
+	// "http://collector:4318/v1/logs" or "http://collector:4318/v1/metrics".
+	Endpoint    string
+	Headers     map[string]string
+	Compression string // "none" (default) or "gzip"
+	Timeout     time.Duration
+	Encoding    string // OTLPEncodingLogs (default) or OTLPEncodingMetrics
+	// AgentIP and NodeName are reported as resource attributes on every exported batch.
+	AgentIP               string
+	NodeName              string
+	TLSEnable             bool
+	TLSInsecureSkipVerify bool
+	TLSCACertPath         string
+	TLSUserCertPath       string
+	TLSUserKeyPath        string
+}
+
+// OTLPProto exports flows to an OpenTelemetry Collector over OTLP/HTTP, either as log records
+// (one per flow, Encoding "logs") or as Sum data points aggregating byte/packet counters
+// (Encoding "metrics"), so the agent can feed an existing OTel pipeline (Tempo, Loki, Mimir,
+// vendor backends) without going through gRPC-to-flowlogs-pipeline or Kafka.
+type OTLPProto struct {
+	cfg    OTLPConfig
+	url    string
+	client *http.Client
+}
+
+// NewOTLPProto builds an OTLPProto exporter from cfg.
+func NewOTLPProto(cfg OTLPConfig) (*OTLPProto, error) {
+	if cfg.Endpoint == "" {
+		return nil, fmt.Errorf("OTLP exporter requires a non-empty endpoint")
+	}
+	switch cfg.Compression {
+	case "", "none", "gzip":
+	default:
+		return nil, fmt.Errorf("unsupported OTLP compression %q", cfg.Compression)
+	}
+	client := &http.Client{Timeout: cfg.Timeout}
+	if cfg.TLSEnable {
+		client.Transport = &http.Transport{TLSClientConfig: otlpTLSConfig(cfg)}
+	}
+	return &OTLPProto{cfg: cfg, url: cfg.Endpoint, client: client}, nil
+}
+
+func otlpTLSConfig(cfg OTLPConfig) *tls.Config {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12, InsecureSkipVerify: cfg.TLSInsecureSkipVerify} //nolint:gosec // explicit opt-in
+	if cfg.TLSCACertPath != "" {
+		pool := x509.NewCertPool()
+		if ca, err := os.ReadFile(cfg.TLSCACertPath); err == nil {
+			pool.AppendCertsFromPEM(ca)
+			tlsCfg.RootCAs = pool
+		} else {
+			otlog.WithError(err).Warn("can't read OTLP CA certificate")
+		}
+	}
+	if cfg.TLSUserCertPath != "" && cfg.TLSUserKeyPath != "" {
+		if cert, err := tls.LoadX509KeyPair(cfg.TLSUserCertPath, cfg.TLSUserKeyPath); err == nil {
+			tlsCfg.Certificates = []tls.Certificate{cert}
+		} else {
+			otlog.WithError(err).Warn("can't load OTLP client certificate")
+		}
+	}
+	return tlsCfg
+}
+
+func (o *OTLPProto) ExportFlows(in <-chan []*flow.Record) {
+	otlog.Info("starting OTLP exporter")
+	for records := range in {
+		if len(records) == 0 {
+			continue
+		}
+		var err error
+		if o.cfg.Encoding == OTLPEncodingMetrics {
+			err = o.postMetrics(records)
+		} else {
+			err = o.postLogs(records)
+		}
+		if err != nil {
+			otlog.WithError(err).Warn("can't export to OTLP collector. Dropping records")
+		}
+	}
+}
+
+func (o *OTLPProto) postLogs(records []*flow.Record) error {
+	logRecords := make([]otlpLogRecord, 0, len(records))
+	for _, r := range records {
+		logRecords = append(logRecords, otlpLogRecord{
+			TimeUnixNano: nsToFlowMillis(r.Metrics.EndMonoTimeTs) * 1e6,
+			Attributes:   flowAttributes(r),
+		})
+	}
+	body := otlpLogsRequest{
+		ResourceLogs: []otlpResourceLogs{{
+			Resource: otlpResource{Attributes: o.resourceAttributes()},
+			ScopeLogs: []otlpScopeLogs{{
+				LogRecords: logRecords,
+			}},
+		}},
+	}
+	return o.post(body)
+}
+
+func (o *OTLPProto) postMetrics(records []*flow.Record) error {
+	var totalBytes, totalPackets uint64
+	for _, r := range records {
+		totalBytes += r.Metrics.Bytes
+		totalPackets += uint64(r.Metrics.Packets)
+	}
+	nowNano := time.Now().UnixNano()
+	body := otlpMetricsRequest{
+		ResourceMetrics: []otlpResourceMetrics{{
+			Resource: otlpResource{Attributes: o.resourceAttributes()},
+			ScopeMetrics: []otlpScopeMetrics{{
+				Metrics: []otlpMetric{
+					{
+						Name: "netobserv.flow.bytes",
+						Sum:  otlpSum{IsMonotonic: true, DataPoints: []otlpDataPoint{{TimeUnixNano: nowNano, AsInt: totalBytes}}},
+					},
+					{
+						Name: "netobserv.flow.packets",
+						Sum:  otlpSum{IsMonotonic: true, DataPoints: []otlpDataPoint{{TimeUnixNano: nowNano, AsInt: totalPackets}}},
+					},
+				},
+			}},
+		}},
+	}
+	return o.post(body)
+}
+
+func (o *OTLPProto) resourceAttributes() []otlpAttribute {
+	attrs := []otlpAttribute{}
+	if o.cfg.AgentIP != "" {
+		attrs = append(attrs, otlpAttribute{Key: "netobserv.agent.ip", Value: otlpValue{StringValue: o.cfg.AgentIP}})
+	}
+	if o.cfg.NodeName != "" {
+		attrs = append(attrs, otlpAttribute{Key: "k8s.node.name", Value: otlpValue{StringValue: o.cfg.NodeName}})
+	}
+	return attrs
+}
+
+func flowAttributes(r *flow.Record) []otlpAttribute {
+	return []otlpAttribute{
+		{Key: "net.src.ip", Value: otlpValue{StringValue: net.IP(r.Id.SrcIp[:]).String()}},
+		{Key: "net.dst.ip", Value: otlpValue{StringValue: net.IP(r.Id.DstIp[:]).String()}},
+		{Key: "net.src.port", Value: otlpValue{StringValue: strconv.Itoa(int(r.Id.SrcPort))}},
+		{Key: "net.dst.port", Value: otlpValue{StringValue: strconv.Itoa(int(r.Id.DstPort))}},
+		{Key: "net.transport.protocol", Value: otlpValue{StringValue: strconv.Itoa(int(r.Id.TransportProtocol))}},
+		{Key: "net.if.index", Value: otlpValue{StringValue: strconv.Itoa(int(r.Id.IfIndex))}},
+		{Key: "net.bytes", Value: otlpValue{StringValue: strconv.FormatUint(r.Metrics.Bytes, 10)}},
+		{Key: "net.packets", Value: otlpValue{StringValue: strconv.FormatUint(uint64(r.Metrics.Packets), 10)}},
+	}
+}
+
+// post sends body (already resolved to a /v1/logs or /v1/metrics OTLP JSON request) to
+// o.cfg.Endpoint, which the operator is expected to set to the matching OTLP signal path, e.g.
+// "http://collector:4318/v1/logs" for OTLPEncodingLogs.
+func (o *OTLPProto) post(body any) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("encoding OTLP payload: %w", err)
+	}
+
+	url := o.url
+	contentEncoding := ""
+	if o.cfg.Compression == "gzip" {
+		var buf bytes.Buffer
+		gz := gzip.NewWriter(&buf)
+		if _, err := gz.Write(payload); err != nil {
+			return fmt.Errorf("gzip-compressing OTLP payload: %w", err)
+		}
+		if err := gz.Close(); err != nil {
+			return fmt.Errorf("closing gzip writer: %w", err)
+		}
+		payload = buf.Bytes()
+		contentEncoding = "gzip"
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), o.cfg.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("building OTLP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
+	for k, v := range o.cfg.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := o.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting to OTLP collector: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("OTLP collector returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// The following types are a minimal subset of the OTLP/HTTP JSON request bodies (cf.
+// opentelemetry-proto's logs.proto/metrics.proto), hand-encoded here since this agent doesn't
+// vendor the generated OTLP protobuf/gRPC stubs.
+
+type otlpAttribute struct {
+	Key   string    `json:"key"`
+	Value otlpValue `json:"value"`
+}
+
+type otlpValue struct {
+	StringValue string `json:"stringValue,omitempty"`
+}
+
+type otlpResource struct {
+	Attributes []otlpAttribute `json:"attributes"`
+}
+
+type otlpLogsRequest struct {
+	ResourceLogs []otlpResourceLogs `json:"resourceLogs"`
+}
+
+type otlpResourceLogs struct {
+	Resource  otlpResource    `json:"resource"`
+	ScopeLogs []otlpScopeLogs `json:"scopeLogs"`
+}
+
+type otlpScopeLogs struct {
+	LogRecords []otlpLogRecord `json:"logRecords"`
+}
+
+type otlpLogRecord struct {
+	TimeUnixNano uint64          `json:"timeUnixNano"`
+	Attributes   []otlpAttribute `json:"attributes"`
+}
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpScopeMetrics struct {
+	Metrics []otlpMetric `json:"metrics"`
+}
+
+type otlpMetric struct {
+	Name string  `json:"name"`
+	Sum  otlpSum `json:"sum"`
+}
+
+type otlpSum struct {
+	IsMonotonic bool            `json:"isMonotonic"`
+	DataPoints  []otlpDataPoint `json:"dataPoints"`
+}
+
+type otlpDataPoint struct {
+	TimeUnixNano int64  `json:"timeUnixNano"`
+	AsInt        uint64 `json:"asInt"`
+}
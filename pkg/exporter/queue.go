@@ -0,0 +1,135 @@
+package exporter
+
+import (
+	"time"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/flow"
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+var qlog = logrus.WithField("component", "exporter/RetryQueue")
+
+// BatchSink submits a single already-encoded batch of Records to the underlying exporter
+// transport (e.g. KafkaProto.Submit), returning an error if the transport didn't accept it so
+// RetryQueue can retry it instead of the caller silently dropping it.
+type BatchSink func(records []*flow.Record) error
+
+// RetryQueueConfig configures RetryQueue's buffering and retry behavior, modeled after the
+// OpenTelemetry Collector's queued-retry exporter helper: a bounded queue absorbs short
+// collector outages, and a capped exponential backoff retries a failed batch a few times before
+// giving up on it.
+type RetryQueueConfig struct {
+	// QueueSize is the maximum number of batches buffered ahead of Sink. Defaults to 1000.
+	QueueSize int
+	// NumConsumers is how many goroutines concurrently pull batches off the queue and call Sink.
+	// Defaults to 1.
+	NumConsumers int
+	// RetryEnabled, if false, calls Sink once and drops the batch (counted in Dropped) on
+	// failure instead of retrying it.
+	RetryEnabled bool
+	// RetryInitialInterval is the delay before the first retry. Defaults to 1s.
+	RetryInitialInterval time.Duration
+	// RetryMaxInterval caps the exponentially-growing delay between retries. Defaults to 30s.
+	RetryMaxInterval time.Duration
+	// RetryMaxElapsedTime bounds how long RetryQueue keeps retrying a single batch before
+	// dropping it. 0 means retry forever.
+	RetryMaxElapsedTime time.Duration
+}
+
+// RetryQueue sits between the tracer/accounter pipeline and a flow exporter's transport,
+// decoupling the rate flows are produced at from the rate (and availability of) the downstream
+// collector, so a slow or temporarily unreachable collector doesn't block flow accounting, and
+// a producer error doesn't silently drop the batch.
+type RetryQueue struct {
+	cfg   RetryQueueConfig
+	sink  BatchSink
+	queue chan []*flow.Record
+
+	queueSize prometheus.Gauge
+	retries   prometheus.Counter
+	dropped   prometheus.Counter
+}
+
+// NewRetryQueue builds a RetryQueue wrapping sink.
+func NewRetryQueue(cfg RetryQueueConfig, sink BatchSink, m *metrics.Metrics) *RetryQueue {
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 1000
+	}
+	if cfg.NumConsumers <= 0 {
+		cfg.NumConsumers = 1
+	}
+	return &RetryQueue{
+		cfg:       cfg,
+		sink:      sink,
+		queue:     make(chan []*flow.Record, cfg.QueueSize),
+		queueSize: m.CreateExporterQueueSizeGauge(),
+		retries:   m.CreateExporterRetriesCounter(),
+		dropped:   m.CreateExporterDroppedCounter(),
+	}
+}
+
+// ExportFlows matches the flowExporter signature (func(in <-chan []*flow.Record)), so a
+// RetryQueue can be used wherever an exporter's ExportFlows was used directly. It starts
+// cfg.NumConsumers consumer goroutines on first call, then enqueues every batch received from
+// in, dropping (and counting) a batch if the queue is already full rather than blocking the
+// whole tracer->accounter->exporter pipeline behind a slow or unreachable collector.
+func (q *RetryQueue) ExportFlows(in <-chan []*flow.Record) {
+	qlog.WithField("consumers", q.cfg.NumConsumers).Info("starting exporter retry queue")
+	for i := 0; i < q.cfg.NumConsumers; i++ {
+		go q.consume()
+	}
+	for records := range in {
+		select {
+		case q.queue <- records:
+			q.queueSize.Set(float64(len(q.queue)))
+		default:
+			qlog.Warn("exporter queue full; dropping batch")
+			q.dropped.Add(1)
+		}
+	}
+	close(q.queue)
+}
+
+func (q *RetryQueue) consume() {
+	for records := range q.queue {
+		q.queueSize.Set(float64(len(q.queue)))
+		if err := q.submitWithRetry(records); err != nil {
+			qlog.WithError(err).Warn("giving up on batch after exhausting retries; dropping")
+			q.dropped.Add(1)
+		}
+	}
+}
+
+// submitWithRetry calls q.sink, retrying on error with a capped exponential backoff until it
+// succeeds, RetryMaxElapsedTime elapses, or retrying is disabled.
+func (q *RetryQueue) submitWithRetry(records []*flow.Record) error {
+	err := q.sink(records)
+	if err == nil || !q.cfg.RetryEnabled {
+		return err
+	}
+	interval := q.cfg.RetryInitialInterval
+	if interval <= 0 {
+		interval = time.Second
+	}
+	maxInterval := q.cfg.RetryMaxInterval
+	if maxInterval <= 0 {
+		maxInterval = 30 * time.Second
+	}
+	hasDeadline := q.cfg.RetryMaxElapsedTime > 0
+	deadline := time.Now().Add(q.cfg.RetryMaxElapsedTime)
+	for err != nil {
+		if hasDeadline && time.Now().After(deadline) {
+			return err
+		}
+		qlog.WithError(err).WithField("retryIn", interval).Warn("retrying failed batch")
+		q.retries.Add(1)
+		time.Sleep(interval)
+		err = q.sink(records)
+		if interval *= 2; interval > maxInterval {
+			interval = maxInterval
+		}
+	}
+	return nil
+}
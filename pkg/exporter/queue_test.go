@@ -0,0 +1,74 @@
+package exporter
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/flow"
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRetryQueue_DeliversBatch(t *testing.T) {
+	var received atomic.Int32
+	q := NewRetryQueue(RetryQueueConfig{}, func(records []*flow.Record) error {
+		received.Add(int32(len(records)))
+		return nil
+	}, metrics.NewMetrics(&metrics.Settings{}))
+
+	in := make(chan []*flow.Record, 1)
+	in <- []*flow.Record{{}, {}, {}}
+	close(in)
+	q.ExportFlows(in)
+
+	assert.Eventually(t, func() bool { return received.Load() == 3 }, time.Second, time.Millisecond)
+}
+
+func TestRetryQueue_RetriesUntilSuccess(t *testing.T) {
+	var attempts atomic.Int32
+	q := NewRetryQueue(RetryQueueConfig{
+		RetryEnabled:         true,
+		RetryInitialInterval: time.Millisecond,
+		RetryMaxInterval:     time.Millisecond,
+	}, func(records []*flow.Record) error {
+		if attempts.Add(1) < 3 {
+			return errors.New("transient")
+		}
+		return nil
+	}, metrics.NewMetrics(&metrics.Settings{}))
+
+	err := q.submitWithRetry([]*flow.Record{{}})
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), attempts.Load())
+}
+
+func TestRetryQueue_GivesUpWhenRetryDisabled(t *testing.T) {
+	var attempts atomic.Int32
+	q := NewRetryQueue(RetryQueueConfig{}, func(records []*flow.Record) error {
+		attempts.Add(1)
+		return errors.New("permanent")
+	}, metrics.NewMetrics(&metrics.Settings{}))
+
+	err := q.submitWithRetry([]*flow.Record{{}})
+	assert.Error(t, err)
+	assert.Equal(t, int32(1), attempts.Load())
+}
+
+func TestRetryQueue_DropsWhenQueueFull(t *testing.T) {
+	block := make(chan struct{})
+	q := NewRetryQueue(RetryQueueConfig{QueueSize: 1, NumConsumers: 1}, func(records []*flow.Record) error {
+		<-block
+		return nil
+	}, metrics.NewMetrics(&metrics.Settings{}))
+
+	in := make(chan []*flow.Record, 3)
+	in <- []*flow.Record{{}}
+	in <- []*flow.Record{{}}
+	in <- []*flow.Record{{}}
+	close(in)
+	q.ExportFlows(in)
+	close(block)
+}
@@ -0,0 +1,72 @@
+package exporter
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// SchemaRegistryClient is a minimal Confluent Schema Registry client: it registers (or fetches,
+// if already registered) a schema under a subject and caches the resulting schema ID, which is
+// all KafkaAvro needs to build the Confluent wire-format envelope.
+type SchemaRegistryClient struct {
+	BaseURL    string
+	HTTPClient *http.Client
+
+	mu    sync.Mutex
+	cache map[string]int
+}
+
+// NewSchemaRegistryClient creates a client pointed at baseURL (e.g. "http://schema-registry:8081").
+func NewSchemaRegistryClient(baseURL string) *SchemaRegistryClient {
+	return &SchemaRegistryClient{
+		BaseURL:    baseURL,
+		HTTPClient: http.DefaultClient,
+		cache:      map[string]int{},
+	}
+}
+
+type registerSchemaRequest struct {
+	Schema string `json:"schema"`
+}
+
+type registerSchemaResponse struct {
+	ID int `json:"id"`
+}
+
+// SchemaID returns the Schema Registry ID for schema under subject, registering it if it hasn't
+// been registered by this client yet. The Schema Registry itself is idempotent for identical
+// schemas, so registering on every call would be safe but wasteful; the cache avoids that.
+func (c *SchemaRegistryClient) SchemaID(subject, schema string) (int, error) {
+	c.mu.Lock()
+	if id, ok := c.cache[subject]; ok {
+		c.mu.Unlock()
+		return id, nil
+	}
+	c.mu.Unlock()
+
+	body, err := json.Marshal(registerSchemaRequest{Schema: schema})
+	if err != nil {
+		return 0, fmt.Errorf("encoding schema registration request: %w", err)
+	}
+	url := fmt.Sprintf("%s/subjects/%s/versions", c.BaseURL, subject)
+	resp, err := c.HTTPClient.Post(url, "application/vnd.schemaregistry.v1+json", bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("registering schema for subject %q: %w", subject, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, fmt.Errorf("registering schema for subject %q: unexpected status %s", subject, resp.Status)
+	}
+	var parsed registerSchemaResponse
+	if err := json.NewDecoder(resp.Body).Decode(&parsed); err != nil {
+		return 0, fmt.Errorf("decoding schema registration response: %w", err)
+	}
+
+	c.mu.Lock()
+	c.cache[subject] = parsed.ID
+	c.mu.Unlock()
+	return parsed.ID, nil
+}
@@ -1,6 +1,8 @@
 package flow
 
 import (
+	"context"
+	"sync"
 	"time"
 
 	"github.com/gavv/monotime"
@@ -15,7 +17,11 @@ type Accounter struct {
 	maxEntries    int
 	interfaceName string
 	evictTimeout  time.Duration
-	entries       map[RecordKey]RecordMetrics
+	// mut guards entries: Account is the only goroutine that normally touches it, but Drain is
+	// meant to be called from a different goroutine (e.g. agent.Flows tearing down a deleted
+	// interface's sub-pipeline), so both need to agree on who currently owns the map.
+	mut     sync.Mutex
+	entries map[RecordKey]RecordMetrics
 }
 
 var alog = logrus.WithField("component", "flow/Accounter")
@@ -34,14 +40,24 @@ func NewAccounter(interfaceName string, maxEntries int, evictTimeout time.Durati
 // Account runs in a new goroutine. It reads all the records from the input channel
 // and accumulate their metrics internally. Once the metrics have reached their max size
 // or the eviction times out, it evicts all the accumulated flows by the returned channel.
-func (c *Accounter) Account(in <-chan *RawRecord, out chan<- []*Record) {
+//
+// Account returns as soon as ctx is canceled, without evicting its pending entries: a canceled
+// Account is assumed to be mid-teardown (e.g. its interface was deleted), and the caller is
+// expected to call Drain afterwards to flush them instead, since by then nothing is left to race
+// the eviction against.
+func (c *Accounter) Account(ctx context.Context, in <-chan *RawRecord, out chan<- []*Record) {
 	evictTick := time.NewTicker(c.evictTimeout)
 	defer evictTick.Stop()
 	for {
 		select {
+		case <-ctx.Done():
+			alog.Debug("context canceled. Exiting account routine, leaving pending entries for Drain")
+			return
 		case <-evictTick.C:
+			c.mut.Lock()
 			evictingEntries := c.entries
 			c.entries = make(map[RecordKey]RecordMetrics, c.maxEntries)
+			c.mut.Unlock()
 			go evict(c.interfaceName, evictingEntries, out)
 		case record, ok := <-in:
 			if !ok {
@@ -53,6 +69,7 @@ func (c *Accounter) Account(in <-chan *RawRecord, out chan<- []*Record) {
 				alog.Debug("exiting account routine")
 				return
 			}
+			c.mut.Lock()
 			if stored, ok := c.entries[record.RecordKey]; ok {
 				stored.Accumulate(&record.RecordMetrics)
 			} else {
@@ -63,11 +80,32 @@ func (c *Accounter) Account(in <-chan *RawRecord, out chan<- []*Record) {
 				}
 				c.entries[record.RecordKey] = record.RecordMetrics
 			}
+			c.mut.Unlock()
 		}
 
 	}
 }
 
+// Drain atomically snapshots and clears the currently accumulated entries, returning them as
+// Records directly rather than over an evictor channel. It's meant to be called after Account has
+// returned (e.g. once its owning interface's sub-pipeline context is canceled), to flush whatever
+// RecordMetrics it had accumulated but not yet evicted, so tearing down an interface doesn't
+// silently drop the last window of flows observed on it.
+func (c *Accounter) Drain() []*Record {
+	c.mut.Lock()
+	entries := c.entries
+	c.entries = make(map[RecordKey]RecordMetrics, c.maxEntries)
+	c.mut.Unlock()
+
+	now := time.Now()
+	monotonicNow := uint64(monotime.Now())
+	records := make([]*Record, 0, len(entries))
+	for key, metrics := range entries {
+		records = append(records, NewRecord(key, metrics, now, monotonicNow, c.interfaceName))
+	}
+	return records
+}
+
 func evict(interfaceName string, entries map[RecordKey]RecordMetrics, evictor chan<- []*Record) {
 	now := time.Now()
 	monotonicNow := uint64(monotime.Now())
@@ -0,0 +1,46 @@
+package flow
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAccounter_Drain_ReturnsAndClearsPendingEntries(t *testing.T) {
+	acc := NewAccounter("eth0", 100, time.Hour)
+	key := RecordKey{EthProtocol: 1, Transport: Transport{SrcPort: 1234, DstPort: 80}}
+	acc.entries[key] = RecordMetrics{Packets: 3, Bytes: 300}
+
+	drained := acc.Drain()
+	require.Len(t, drained, 1)
+	assert.Equal(t, RecordMetrics{Packets: 3, Bytes: 300}, drained[0].RecordMetrics)
+	assert.Empty(t, acc.entries, "Drain should leave no pending entries behind")
+
+	assert.Empty(t, acc.Drain(), "a second Drain with nothing new accumulated should be empty")
+}
+
+func TestAccounter_Account_ExitsOnContextCancelWithoutEvicting(t *testing.T) {
+	acc := NewAccounter("eth0", 100, time.Hour)
+	acc.entries[RecordKey{EthProtocol: 1}] = RecordMetrics{Packets: 1}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan *RawRecord)
+	out := make(chan []*Record, 1)
+	done := make(chan struct{})
+	go func() {
+		acc.Account(ctx, in, out)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected Account to return after context cancellation")
+	}
+	assert.Empty(t, out, "Account should leave eviction to a follow-up Drain, not evict on cancel")
+	assert.Len(t, acc.Drain(), 1, "the entry accumulated before cancellation should still be there for Drain")
+}
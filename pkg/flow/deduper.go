@@ -25,11 +25,16 @@ type deduperCache struct {
 }
 
 type entry struct {
-	key        *ebpf.BpfFlowId
-	dnsRecord  *ebpf.BpfDnsRecordT
-	ifIndex    uint32
-	expiryTime time.Time
-	dupList    *[]map[string]uint8
+	key          *ebpf.BpfFlowId
+	dnsRecord    *ebpf.BpfDnsRecordT
+	l7Record     **ebpf.L7Record
+	dropReason   **ebpf.DropReason
+	denied       *bool
+	svcAddress   *[16]byte
+	svcPort      *uint16
+	ifIndex      uint32
+	expiryTime   time.Time
+	dupList      *[]map[string]uint8
 }
 
 // Dedupe receives flows and filters these belonging to duplicate interfaces. It will forward
@@ -83,6 +88,33 @@ func (c *deduperCache) checkDupe(r *Record, justMark, mergeDup bool, fwd *[]*Rec
 			fEntry.dnsRecord.Latency = r.Metrics.DnsRecord.Latency
 			// fall through to do interface check
 		}
+		// Same idea for L7 (HTTP/gRPC/TLS-SNI) enrichment: the TC hook on the interface that
+		// actually saw the parseable first packets of the flow might not be the one that won
+		// the race to be cached here first, so adopt its L7Record rather than drop it.
+		//
+		// r.L7 is always nil in production today: nothing in pkg/agent constructs the
+		// flow.MapTracer that would set it (see MapTracer's doc comment), and even that tracer's
+		// L7 source, ebpf.FlowFetcher.L7Records, always returns nil since this build doesn't
+		// ship the BPF program that would populate l7_records. This merge only exercises with a
+		// manually-constructed *Record in deduper_test.go.
+		if r.L7 != nil && *fEntry.l7Record == nil {
+			*fEntry.l7Record = r.L7
+		}
+		// Same for a denied-flow report: a drop/deny verdict seen on a duplicate interface
+		// enriches the cached entry instead of being forwarded as a second, reason-less record.
+		if r.DropReason != nil && *fEntry.dropReason == nil {
+			*fEntry.dropReason = r.DropReason
+			*fEntry.denied = true
+		}
+		// Same for the pre-DNAT Service address: whichever interface's conntrack lookup
+		// resolves first enriches the cached entry, the same as DNS/L7/DropReason above. The
+		// dedup key itself still ignores it: it's derived from the post-DNAT 5-tuple the kernel
+		// actually forwards, and a pre-DNAT VIP resolved on one hook but not another must not
+		// split a single real flow into two.
+		if r.DestinationServicePort != 0 && *fEntry.svcPort == 0 {
+			*fEntry.svcAddress = r.DestinationServiceAddress
+			*fEntry.svcPort = r.DestinationServicePort
+		}
 		if fEntry.ifIndex != r.Id.IfIndex {
 			if justMark {
 				r.Duplicate = true
@@ -102,6 +134,11 @@ func (c *deduperCache) checkDupe(r *Record, justMark, mergeDup bool, fwd *[]*Rec
 	e := entry{
 		key:        &rk,
 		dnsRecord:  &r.Metrics.DnsRecord,
+		l7Record:   &r.L7,
+		dropReason: &r.DropReason,
+		denied:     &r.Denied,
+		svcAddress: &r.DestinationServiceAddress,
+		svcPort:    &r.DestinationServicePort,
 		ifIndex:    r.Id.IfIndex,
 		expiryTime: timeNow().Add(c.expire),
 	}
@@ -0,0 +1,102 @@
+package flow
+
+import (
+	"container/list"
+	"testing"
+	"time"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/ebpf"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newDeduperCache() *deduperCache {
+	return &deduperCache{
+		expire:  time.Minute,
+		entries: list.New(),
+		ifaces:  map[ebpf.BpfFlowId]*list.Element{},
+	}
+}
+
+func TestDeduperCache_AdoptsL7FromLaterDuplicateInterface(t *testing.T) {
+	cache := newDeduperCache()
+
+	first := &Record{Interface: "eth0", Id: ebpf.BpfFlowId{SrcPort: 1}}
+	var fwd []*Record
+	cache.checkDupe(first, false, false, &fwd)
+	require.Len(t, fwd, 1)
+
+	dup := &Record{
+		Interface: "eth1",
+		Id:        ebpf.BpfFlowId{SrcPort: 1, IfIndex: 2},
+		L7:        &ebpf.L7Record{Proto: "HTTP/1.1", Method: "GET"},
+	}
+	fwd = nil
+	cache.checkDupe(dup, false, false, &fwd)
+	assert.Empty(t, fwd, "a duplicate on a different interface is dropped when justMark is false")
+	require.NotNil(t, first.L7, "the cached record should have adopted the duplicate's L7Record")
+	assert.Equal(t, "GET", first.L7.Method)
+}
+
+func TestDeduperCache_AdoptsDropReasonFromLaterDuplicateInterface(t *testing.T) {
+	cache := newDeduperCache()
+
+	first := &Record{Interface: "eth0", Id: ebpf.BpfFlowId{SrcPort: 1}}
+	var fwd []*Record
+	cache.checkDupe(first, false, false, &fwd)
+	require.Len(t, fwd, 1)
+
+	dup := &Record{
+		Interface:  "eth1",
+		Id:         ebpf.BpfFlowId{SrcPort: 1, IfIndex: 2},
+		DropReason: &ebpf.DropReason{Reason: ebpf.DropReasonPolicyDeny, PolicyRef: "deny-all"},
+	}
+	fwd = nil
+	cache.checkDupe(dup, false, false, &fwd)
+	assert.Empty(t, fwd, "a duplicate on a different interface is dropped when justMark is false")
+	require.NotNil(t, first.DropReason, "the cached record should have adopted the duplicate's DropReason")
+	assert.Equal(t, "deny-all", first.DropReason.PolicyRef)
+	assert.True(t, first.Denied)
+}
+
+func TestDeduperCache_AdoptsServiceAddressFromLaterDuplicateInterface(t *testing.T) {
+	cache := newDeduperCache()
+
+	first := &Record{Interface: "eth0", Id: ebpf.BpfFlowId{SrcPort: 1}}
+	var fwd []*Record
+	cache.checkDupe(first, false, false, &fwd)
+	require.Len(t, fwd, 1)
+
+	dup := &Record{
+		Interface:                 "eth1",
+		Id:                        ebpf.BpfFlowId{SrcPort: 1, IfIndex: 2},
+		DestinationServiceAddress: [16]byte{10, 0, 0, 1},
+		DestinationServicePort:    80,
+	}
+	fwd = nil
+	cache.checkDupe(dup, false, false, &fwd)
+	assert.Empty(t, fwd, "a duplicate on a different interface is dropped when justMark is false")
+	assert.Equal(t, uint16(80), first.DestinationServicePort, "the cached record should have adopted the duplicate's Service address")
+	assert.Equal(t, [16]byte{10, 0, 0, 1}, first.DestinationServiceAddress)
+}
+
+func TestDeduperCache_KeepsItsOwnL7OverADuplicates(t *testing.T) {
+	cache := newDeduperCache()
+
+	first := &Record{
+		Interface: "eth0",
+		Id:        ebpf.BpfFlowId{SrcPort: 1},
+		L7:        &ebpf.L7Record{Proto: "HTTP/1.1", Method: "GET"},
+	}
+	var fwd []*Record
+	cache.checkDupe(first, false, false, &fwd)
+
+	dup := &Record{
+		Interface: "eth1",
+		Id:        ebpf.BpfFlowId{SrcPort: 1, IfIndex: 2},
+		L7:        &ebpf.L7Record{Proto: "HTTP/1.1", Method: "POST"},
+	}
+	fwd = nil
+	cache.checkDupe(dup, false, false, &fwd)
+	assert.Equal(t, "GET", first.L7.Method, "an already-enriched cached record keeps its own L7Record")
+}
@@ -0,0 +1,127 @@
+package flow
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/ebpf"
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/enricher"
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/metrics"
+
+	"github.com/netobserv/gopipes/pkg/node"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+var etlog = logrus.WithField("component", "flow.EndpointTracer")
+
+// EndpointUsage is a rolling bytes-in/bytes-out accounting for a single MAC/IP endpoint,
+// produced by EndpointTracer as a lightweight alternative to full 5-tuple flow Records.
+type EndpointUsage struct {
+	Mac      string
+	IP       string
+	BytesIn  uint64
+	BytesOut uint64
+	// PodName and PodNamespace are only set when EndpointTracer was built with a podResolver
+	// and it resolved IP to a known Pod. They let per-tenant usage reporting group by
+	// workload instead of by raw MAC/IP, without changing the kernel map's key granularity.
+	PodName      string `json:",omitempty"`
+	PodNamespace string `json:",omitempty"`
+}
+
+// podResolver resolves an endpoint's IP address to the Pod currently bound to it. Its shape
+// matches enricher.OVNEnricher.LookupByIP, letting EndpointTracer reuse the same logical-port
+// cache that enricher.OVNEnricher already maintains, rather than standing up a separate k8s-IP
+// index just for endpoint accounting.
+type podResolver interface {
+	LookupByIP(ip string) (enricher.LogicalPortInfo, bool)
+}
+
+// endpointFetcher abstracts the eBPF BPF_MAP_TYPE_LRU_HASH sidecar map EndpointTracer reads
+// from, matching the shape of ebpf.EndpointFetcher.
+type endpointFetcher interface {
+	LookupAndDeleteMap() map[ebpf.EndpointKey]ebpf.EndpointBytes
+}
+
+// EndpointTracer periodically drains the endpoint bandwidth accounting map and reports
+// per-endpoint usage, both as a Prometheus metric and as a forwarded stream that an exporter
+// can optionally pick up. Unlike MapTracer it doesn't need watermark-driven adaptive eviction:
+// the underlying map is a BPF_MAP_TYPE_LRU_HASH, so the kernel ages out endpoints under memory
+// pressure without userspace involvement.
+//
+// Nothing in pkg/agent constructs an EndpointTracer yet: Config.EnableEndpointAccounting and
+// Config.EndpointAccountingPollInterval are parsed but unread, and FlowsAgent has no alternate
+// exporter path for []*EndpointUsage alongside its []*flow.Record one. Wiring this in requires
+// its own TraceLoop/exporter pair in agent.Flows, not just constructing the type; that's left
+// for a follow-up rather than bundled into this change.
+type EndpointTracer struct {
+	fetcher      endpointFetcher
+	pollInterval time.Duration
+	bytesTotal   *prometheus.CounterVec
+	podResolver  podResolver
+}
+
+// NewEndpointTracer creates an EndpointTracer that polls fetcher every pollInterval.
+func NewEndpointTracer(fetcher endpointFetcher, pollInterval time.Duration, m *metrics.Metrics) *EndpointTracer {
+	return &EndpointTracer{
+		fetcher:      fetcher,
+		pollInterval: pollInterval,
+		bytesTotal:   m.CreateEndpointBytesCounter(),
+	}
+}
+
+// WithPodResolver enables resolving each reported endpoint's IP to a Pod name/namespace via
+// resolver (e.g. an *enricher.OVNEnricher already started by the agent for flow enrichment),
+// populating EndpointUsage.PodName/PodNamespace. Call before TraceLoop; it's not safe to set
+// concurrently with a running poll.
+//
+// Like EndpointTracer itself, this has no caller in pkg/agent yet: Config.
+// EndpointAccountingResolvePods is parsed but unread, since there's no constructed OVNEnricher
+// or EndpointTracer in FlowsAgent to pass it to.
+func (t *EndpointTracer) WithPodResolver(resolver podResolver) *EndpointTracer {
+	t.podResolver = resolver
+	return t
+}
+
+// TraceLoop polls the endpoint accounting map every pollInterval and forwards the resulting
+// EndpointUsage batches until the context is canceled.
+func (t *EndpointTracer) TraceLoop(ctx context.Context) node.StartFunc[[]*EndpointUsage] {
+	return func(out chan<- []*EndpointUsage) {
+		ticker := time.NewTicker(t.pollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				etlog.Debug("exiting endpoint trace loop due to context cancellation")
+				return
+			case <-ticker.C:
+				t.poll(out)
+			}
+		}
+	}
+}
+
+func (t *EndpointTracer) poll(out chan<- []*EndpointUsage) {
+	entries := t.fetcher.LookupAndDeleteMap()
+	if len(entries) == 0 {
+		return
+	}
+	usages := make([]*EndpointUsage, 0, len(entries))
+	for key, counters := range entries {
+		mac := net.HardwareAddr(key.Mac[:]).String()
+		ip := net.IP(key.IP[:]).String()
+		t.bytesTotal.WithLabelValues("in", mac, ip).Add(float64(counters.BytesIn))
+		t.bytesTotal.WithLabelValues("out", mac, ip).Add(float64(counters.BytesOut))
+		usage := &EndpointUsage{Mac: mac, IP: ip, BytesIn: counters.BytesIn, BytesOut: counters.BytesOut}
+		if t.podResolver != nil {
+			if info, ok := t.podResolver.LookupByIP(ip); ok {
+				usage.PodName = info.PodName
+				usage.PodNamespace = info.PodNamespace
+			}
+		}
+		usages = append(usages, usage)
+	}
+	etlog.Debugf("%d endpoints reported", len(usages))
+	out <- usages
+}
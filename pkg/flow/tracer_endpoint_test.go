@@ -0,0 +1,100 @@
+package flow
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/ebpf"
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/enricher"
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeEndpointFetcher struct {
+	entries map[ebpf.EndpointKey]ebpf.EndpointBytes
+}
+
+func (f *fakeEndpointFetcher) LookupAndDeleteMap() map[ebpf.EndpointKey]ebpf.EndpointBytes {
+	entries := f.entries
+	f.entries = nil
+	return entries
+}
+
+func TestEndpointTracer_Poll_ReportsUsageAndDrainsTheMap(t *testing.T) {
+	key := ebpf.EndpointKey{Mac: [6]uint8{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}, IP: [16]uint8{10, 0, 0, 1}}
+	fetcher := &fakeEndpointFetcher{entries: map[ebpf.EndpointKey]ebpf.EndpointBytes{
+		key: {BytesIn: 100, BytesOut: 50},
+	}}
+	tracer := NewEndpointTracer(fetcher, time.Hour, metrics.NewMetrics(&metrics.Settings{}))
+
+	out := make(chan []*EndpointUsage, 1)
+	tracer.poll(out)
+
+	require.Len(t, out, 1)
+	usages := <-out
+	require.Len(t, usages, 1)
+	assert.Equal(t, uint64(100), usages[0].BytesIn)
+	assert.Equal(t, uint64(50), usages[0].BytesOut)
+	assert.Nil(t, fetcher.entries, "poll should drain the underlying map")
+}
+
+type fakePodResolver struct {
+	byIP map[string]enricher.LogicalPortInfo
+}
+
+func (f *fakePodResolver) LookupByIP(ip string) (enricher.LogicalPortInfo, bool) {
+	info, ok := f.byIP[ip]
+	return info, ok
+}
+
+func TestEndpointTracer_Poll_ResolvesPodWhenResolverSet(t *testing.T) {
+	key := ebpf.EndpointKey{Mac: [6]uint8{0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff}, IP: [16]uint8{10, 0, 0, 1}}
+	fetcher := &fakeEndpointFetcher{entries: map[ebpf.EndpointKey]ebpf.EndpointBytes{
+		key: {BytesIn: 100, BytesOut: 50},
+	}}
+	resolver := &fakePodResolver{byIP: map[string]enricher.LogicalPortInfo{
+		net.IP(key.IP[:]).String(): {PodName: "my-pod", PodNamespace: "my-ns"},
+	}}
+	tracer := NewEndpointTracer(fetcher, time.Hour, metrics.NewMetrics(&metrics.Settings{})).WithPodResolver(resolver)
+
+	out := make(chan []*EndpointUsage, 1)
+	tracer.poll(out)
+
+	usages := <-out
+	require.Len(t, usages, 1)
+	assert.Equal(t, "my-pod", usages[0].PodName)
+	assert.Equal(t, "my-ns", usages[0].PodNamespace)
+}
+
+func TestEndpointTracer_Poll_NoEntriesDoesNotForward(t *testing.T) {
+	fetcher := &fakeEndpointFetcher{}
+	tracer := NewEndpointTracer(fetcher, time.Hour, metrics.NewMetrics(&metrics.Settings{}))
+
+	out := make(chan []*EndpointUsage, 1)
+	tracer.poll(out)
+
+	assert.Empty(t, out)
+}
+
+func TestEndpointTracer_TraceLoop_StopsOnContextCancel(t *testing.T) {
+	fetcher := &fakeEndpointFetcher{}
+	tracer := NewEndpointTracer(fetcher, time.Millisecond, metrics.NewMetrics(&metrics.Settings{}))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	out := make(chan []*EndpointUsage)
+	done := make(chan struct{})
+	go func() {
+		tracer.TraceLoop(ctx)(out)
+		close(done)
+	}()
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("expected TraceLoop to return after context cancellation")
+	}
+}
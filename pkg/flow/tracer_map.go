@@ -17,26 +17,69 @@ import (
 
 var mtlog = logrus.WithField("component", "flow.MapTracer")
 
-// MapTracer accesses a mapped source of flows (the eBPF PerCPU HashMap), deserializes it into
-// a flow Record structure, and performs the accumulation of each perCPU-record into a single flow
+// MapTracer accesses a mapped source of flows (the eBPF aggregated_flows BPF_MAP_TYPE_HASH map,
+// updated under a bpf_spin_lock so a single value per key is always consistent) and deserializes
+// it into a flow Record structure.
+//
+// Nothing in pkg/agent constructs a MapTracer yet (see agent.Flows.tracerFactory), so this type
+// -- including DrainShadowMap's shadow-map merge, the eviction logic below, and the
+// L7/DropReason/service-mapping merges in evictFlows -- only runs in its own tests, not the live
+// agent.
 type MapTracer struct {
 	mapFetcher               mapFetcher
 	evictionTimeout          time.Duration
 	staleEntriesEvictTimeout time.Duration
+	// highWatermarkRatio and criticalWatermarkRatio are map-fill ratios (0..1): checkWatermarks
+	// only logs a fill ratio at or above highWatermarkRatio, and forces an immediate eviction,
+	// regardless of the configured evictionTimeout, at or above criticalWatermarkRatio. The fill
+	// ratio is sampled every watermarkPollInterval() whatever its value.
+	highWatermarkRatio     float64
+	criticalWatermarkRatio float64
 	// manages the access to the eviction routines, avoiding two evictions happening at the same time
 	evictionCond               *sync.Cond
 	lastEvictionNs             uint64
 	hmapEvictionCounter        prometheus.Counter
 	numberOfEvictedFlows       prometheus.Counter
 	timeSpentinLookupAndDelete prometheus.Histogram
+	mapFillRatio               prometheus.Gauge
+	adaptiveEvictionCounter    prometheus.Counter
 	errors                     *metrics.ErrorCounter
 }
 
 type mapFetcher interface {
-	LookupAndDeleteMap(counter prometheus.Counter) map[ebpf.BpfFlowId][]ebpf.BpfFlowMetrics
+	// LookupAndDeleteMap drains aggregated_flows, returning a single *BpfFlowMetrics per key
+	// (the map holds one spin-lock-protected value per flow, not a per-CPU slice to reduce).
+	LookupAndDeleteMap(counter prometheus.Counter) map[ebpf.BpfFlowId]*ebpf.BpfFlowMetrics
+	// DrainShadowMap drains the kernel-side LRU shadow map: flows that couldn't be inserted
+	// into aggregated_flows because it was full on every CPU at once (-E2BIG) are recorded
+	// there instead, so this eviction cycle doesn't lose them outright. A key present in both
+	// the primary and the shadow map means the primary entry already has everything the
+	// kernel tracked, since the shadow copy is only ever written on an insert failure.
+	DrainShadowMap() map[ebpf.BpfFlowId]*ebpf.BpfFlowMetrics
+	// L7Records drains the l7_records map, returning the HTTP/1.x or HTTP/2 metadata parsed
+	// for each flow since the last call. It's empty, not an error, when EnableL7Tracking is
+	// off or unsupported, since L7 metadata is always optional enrichment.
+	L7Records() map[ebpf.BpfFlowId]*ebpf.L7Record
+	// DropReasons drains the drop_reasons map, returning the classifier's verdict for each
+	// denied flow seen since the last call. It's empty, not an error, when the classifier hook
+	// is off or unsupported, since drop reason metadata is always optional enrichment.
+	DropReasons() map[ebpf.BpfFlowId]*ebpf.DropReason
+	// ServiceMappings drains the service_mappings map, returning the pre-DNAT Service
+	// address/port a conntrack lookup resolved for each flow seen since the last call. It's
+	// empty, not an error, when no conntrack source is wired up, since service mapping is
+	// always optional enrichment.
+	ServiceMappings() map[ebpf.BpfFlowId]*ebpf.ServiceMapping
 	DeleteMapsStaleEntries(timeOut time.Duration)
+	// MapFillRatio returns the fraction (0..1) of the aggregated-flows map that is currently
+	// occupied, used by MapTracer to decide whether to evict ahead of the configured schedule.
+	MapFillRatio() float64
 }
 
+// var _ mapFetcher = ... fails to compile the moment FlowFetcher's method set drifts from
+// mapFetcher again, instead of that only surfacing at the NewMapTracer(fetcher, ...) call site
+// (or, worse, not at all if nothing ever calls it with a real *ebpf.FlowFetcher).
+var _ mapFetcher = (*ebpf.FlowFetcher)(nil)
+
 func NewMapTracer(fetcher mapFetcher, evictionTimeout, staleEntriesEvictTimeout time.Duration, m *metrics.Metrics) *MapTracer {
 	return &MapTracer{
 		mapFetcher:                 fetcher,
@@ -44,13 +87,25 @@ func NewMapTracer(fetcher mapFetcher, evictionTimeout, staleEntriesEvictTimeout
 		lastEvictionNs:             uint64(monotime.Now()),
 		evictionCond:               sync.NewCond(&sync.Mutex{}),
 		staleEntriesEvictTimeout:   staleEntriesEvictTimeout,
+		highWatermarkRatio:         0.75,
+		criticalWatermarkRatio:     0.9,
 		hmapEvictionCounter:        m.CreateHashMapCounter(),
 		numberOfEvictedFlows:       m.CreateNumberOfEvictedFlows(),
 		timeSpentinLookupAndDelete: m.CreateTimeSpendInLookupAndDelete(),
+		mapFillRatio:               m.CreateMapFillRatioGauge(),
+		adaptiveEvictionCounter:    m.CreateAdaptiveEvictionCounter(),
 		errors:                     m.GetErrorsCounter(),
 	}
 }
 
+// WithWatermarks overrides the default high/critical map-fill watermarks (0.75/0.9), e.g. from
+// Config.CacheMaxFillRatio.
+func (m *MapTracer) WithWatermarks(high, critical float64) *MapTracer {
+	m.highWatermarkRatio = high
+	m.criticalWatermarkRatio = critical
+	return m
+}
+
 // Flush forces reading (and removing) all the flows from the source eBPF map
 // and sending the entries to the next stage in the pipeline
 func (m *MapTracer) Flush() {
@@ -60,21 +115,55 @@ func (m *MapTracer) Flush() {
 func (m *MapTracer) TraceLoop(ctx context.Context, forceGC bool) node.StartFunc[[]*Record] {
 	return func(out chan<- []*Record) {
 		evictionTicker := time.NewTicker(m.evictionTimeout)
+		// the watermark ticker samples the map fill ratio at a fraction of the eviction
+		// timeout, so a burst of traffic gets evicted well before the map actually fills up
+		watermarkTicker := time.NewTicker(m.watermarkPollInterval())
 		go m.evictionSynchronization(ctx, forceGC, out)
 		for {
 			select {
 			case <-ctx.Done():
 				evictionTicker.Stop()
+				watermarkTicker.Stop()
 				mtlog.Debug("exiting trace loop due to context cancellation")
 				return
 			case <-evictionTicker.C:
 				mtlog.Debug("triggering flow eviction on timer")
 				m.Flush()
+			case <-watermarkTicker.C:
+				m.checkWatermarks()
 			}
 		}
 	}
 }
 
+// watermarkPollInterval is how often the map fill ratio is sampled: a quarter of the eviction
+// timeout, capped between 100ms and 1s, so it reacts fast without busy-polling the map.
+func (m *MapTracer) watermarkPollInterval() time.Duration {
+	interval := m.evictionTimeout / 4
+	if interval < 100*time.Millisecond {
+		return 100 * time.Millisecond
+	}
+	if interval > time.Second {
+		return time.Second
+	}
+	return interval
+}
+
+// checkWatermarks samples the current map fill ratio and forces an immediate eviction if it is
+// at or above the critical watermark, logging a warning since it means the configured
+// evictionTimeout is too long for the current flow rate.
+func (m *MapTracer) checkWatermarks() {
+	ratio := m.mapFetcher.MapFillRatio()
+	m.mapFillRatio.Set(ratio)
+	if ratio >= m.criticalWatermarkRatio {
+		mtlog.WithField("fillRatio", ratio).Warn("map fill ratio above critical watermark: forcing eviction")
+		m.adaptiveEvictionCounter.Inc()
+		m.Flush()
+	} else if ratio >= m.highWatermarkRatio {
+		mtlog.WithField("fillRatio", ratio).Debug("map fill ratio above high watermark")
+	}
+}
+
 // evictionSynchronization loop just waits for the evictionCond to happen
 // and triggers the actual eviction. It makes sure that only one eviction
 // is being triggered at the same time
@@ -106,23 +195,60 @@ func (m *MapTracer) evictFlows(ctx context.Context, forceGC bool, forwardFlows c
 	var forwardingFlows []*Record
 	laterFlowNs := uint64(0)
 	flows := m.mapFetcher.LookupAndDeleteMap(m.errors.WithValues("CannotDeleteFlows", ""))
+	for flowKey, shadowed := range m.mapFetcher.DrainShadowMap() {
+		// the shadow map is only ever written when the kernel couldn't insert into the
+		// primary map, so if flowKey is already there the primary entry is authoritative.
+		if _, ok := flows[flowKey]; !ok {
+			flows[flowKey] = shadowed
+		}
+	}
+	l7Records := m.mapFetcher.L7Records()
+	dropReasons := m.mapFetcher.DropReasons()
+	serviceMappings := m.mapFetcher.ServiceMappings()
 	elapsed := time.Since(currentTime)
 	for flowKey, flowMetrics := range flows {
-		aggregatedMetrics := m.aggregate(flowMetrics)
-		// we ignore metrics that haven't been aggregated (e.g. all the mapped values are ignored)
-		if aggregatedMetrics.EndMonoTimeTs == 0 {
+		// eBPF hashmap values are not zeroed when the entry is removed. That causes that we
+		// might receive entries from previous collect-eviction timeslots.
+		// We need to check the flow time and discard old flows.
+		if flowMetrics.StartMonoTimeTs <= m.lastEvictionNs || flowMetrics.EndMonoTimeTs <= m.lastEvictionNs {
 			continue
 		}
-		// If it iterated an entry that do not have updated flows
-		if aggregatedMetrics.EndMonoTimeTs > laterFlowNs {
-			laterFlowNs = aggregatedMetrics.EndMonoTimeTs
+		if flowMetrics.EndMonoTimeTs > laterFlowNs {
+			laterFlowNs = flowMetrics.EndMonoTimeTs
 		}
-		forwardingFlows = append(forwardingFlows, NewRecord(
+		record := NewRecord(
 			flowKey,
-			aggregatedMetrics,
+			flowMetrics,
 			currentTime,
 			uint64(monotonicTimeNow),
-		))
+		)
+		// L7 is nil unless EnableL7Tracking matched an HTTP/1.x or HTTP/2 request/response
+		// for this flow; downstream exporters already treat it as optional the same way they
+		// treat a zero-value DnsRecord. In practice l7Records is always empty: MapTracer itself
+		// is unreachable from pkg/agent (see the type doc above), and even with a MapTracer
+		// constructed, ebpf.FlowFetcher.L7Records always returns nil (this build doesn't ship
+		// the BPF program that would populate l7_records; see ebpf.L7Records).
+		record.L7 = l7Records[flowKey]
+		// DropReason is nil unless the classifier hook denied this flow; Denied mirrors
+		// Duplicate as the cheap boolean exporters can filter on without inspecting the
+		// reason detail. In practice dropReasons is always empty: MapTracer itself is
+		// unreachable from pkg/agent (see the type doc above), and even with a MapTracer
+		// constructed, ebpf.FlowFetcher.DropReasons always returns nil (this build doesn't
+		// ship the BPF program/map that would populate drop_reasons; see ebpf.DropReasons).
+		if reason, ok := dropReasons[flowKey]; ok {
+			record.DropReason = reason
+			record.Denied = true
+		}
+		// DestinationServiceAddress/Port stay zero-valued unless a conntrack lookup resolved a
+		// pre-DNAT Service tuple for this flow, the same optional-enrichment shape as DropReason.
+		// In practice serviceMappings is always empty for the same two reasons: MapTracer has
+		// no caller in pkg/agent, and ebpf.FlowFetcher.ServiceMappings always returns nil since
+		// this build doesn't ship a live conntrack source; see ebpf.ServiceMappings.
+		if svc, ok := serviceMappings[flowKey]; ok {
+			record.DestinationServiceAddress = svc.OrigDstIP
+			record.DestinationServicePort = svc.OrigDstPort
+		}
+		forwardingFlows = append(forwardingFlows, record)
 	}
 	m.mapFetcher.DeleteMapsStaleEntries(m.staleEntriesEvictTimeout)
 	m.lastEvictionNs = laterFlowNs
@@ -141,21 +267,3 @@ func (m *MapTracer) evictFlows(ctx context.Context, forceGC bool, forwardFlows c
 	m.timeSpentinLookupAndDelete.Observe(elapsed.Seconds())
 	mtlog.Debugf("%d flows evicted", len(forwardingFlows))
 }
-
-func (m *MapTracer) aggregate(metrics []ebpf.BpfFlowMetrics) *ebpf.BpfFlowMetrics {
-	if len(metrics) == 0 {
-		mtlog.Warn("invoked aggregate with no values")
-		return &ebpf.BpfFlowMetrics{}
-	}
-	aggr := &ebpf.BpfFlowMetrics{}
-	for _, mt := range metrics {
-		// eBPF hashmap values are not zeroed when the entry is removed. That causes that we
-		// might receive entries from previous collect-eviction timeslots.
-		// We need to check the flow time and discard old flows.
-		if mt.StartMonoTimeTs <= m.lastEvictionNs || mt.EndMonoTimeTs <= m.lastEvictionNs {
-			continue
-		}
-		Accumulate(aggr, &mt)
-	}
-	return aggr
-}
@@ -0,0 +1,90 @@
+package flow
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/ebpf"
+	"github.com/netobserv/netobserv-ebpf-agent/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeMapFetcher struct {
+	mu        sync.Mutex
+	fillRatio float64
+}
+
+func (f *fakeMapFetcher) LookupAndDeleteMap(_ prometheus.Counter) map[ebpf.BpfFlowId]*ebpf.BpfFlowMetrics {
+	return nil
+}
+
+func (f *fakeMapFetcher) DrainShadowMap() map[ebpf.BpfFlowId]*ebpf.BpfFlowMetrics {
+	return nil
+}
+
+func (f *fakeMapFetcher) L7Records() map[ebpf.BpfFlowId]*ebpf.L7Record {
+	return nil
+}
+
+func (f *fakeMapFetcher) DropReasons() map[ebpf.BpfFlowId]*ebpf.DropReason {
+	return nil
+}
+
+func (f *fakeMapFetcher) ServiceMappings() map[ebpf.BpfFlowId]*ebpf.ServiceMapping {
+	return nil
+}
+
+func (f *fakeMapFetcher) DeleteMapsStaleEntries(_ time.Duration) {}
+
+func (f *fakeMapFetcher) MapFillRatio() float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.fillRatio
+}
+
+func (f *fakeMapFetcher) setFillRatio(r float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.fillRatio = r
+}
+
+func TestMapTracer_CheckWatermarks_ForcesEvictionAboveCritical(t *testing.T) {
+	fetcher := &fakeMapFetcher{}
+	tracer := NewMapTracer(fetcher, time.Hour, time.Hour, metrics.NewMetrics(&metrics.Settings{}))
+
+	evicted := make(chan struct{}, 1)
+	go func() {
+		tracer.evictionCond.L.Lock()
+		tracer.evictionCond.Wait()
+		tracer.evictionCond.L.Unlock()
+		evicted <- struct{}{}
+	}()
+	time.Sleep(10 * time.Millisecond) // let the goroutine start waiting on evictionCond
+
+	fetcher.setFillRatio(0.5)
+	tracer.checkWatermarks()
+	select {
+	case <-evicted:
+		t.Fatal("eviction should not be forced below the critical watermark")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	fetcher.setFillRatio(0.95)
+	tracer.checkWatermarks()
+	select {
+	case <-evicted:
+	case <-time.After(time.Second):
+		t.Fatal("expected checkWatermarks to force eviction above the critical watermark")
+	}
+}
+
+func TestWatermarkPollInterval_Bounds(t *testing.T) {
+	fetcher := &fakeMapFetcher{}
+	tracer := NewMapTracer(fetcher, 100*time.Millisecond, time.Hour, metrics.NewMetrics(&metrics.Settings{}))
+	assert.Equal(t, 100*time.Millisecond, tracer.watermarkPollInterval())
+
+	tracer.evictionTimeout = 10 * time.Second
+	assert.Equal(t, time.Second, tracer.watermarkPollInterval())
+}
@@ -0,0 +1,81 @@
+package ifaces
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+var plog = logrus.WithField("component", "ifaces.Poller")
+
+// Poller periodically lists the system network interfaces and diffs them against the
+// previous snapshot, emitting an EventAdded for each interface that appeared and an
+// EventDeleted for each one that disappeared since the last poll. It trades up to one poll
+// interval of discovery latency for not depending on a netlink subscription. The agent uses it
+// when ListenInterfaces is "poll"; Watcher doesn't fall back to it when its netlink
+// subscription fails, its Subscribe returns the error instead.
+type Poller struct {
+	period time.Duration
+	bufLen int
+	// interfaces is overridable in tests
+	interfaces func() ([]Name, error)
+}
+
+// NewPoller creates a Poller that lists the system interfaces every period.
+func NewPoller(period time.Duration, bufLen int) *Poller {
+	return &Poller{period: period, bufLen: bufLen, interfaces: netInterfaces}
+}
+
+func (p *Poller) Subscribe(ctx context.Context) (<-chan Event, error) {
+	out := make(chan Event, p.bufLen)
+	go p.run(ctx, out)
+	return out, nil
+}
+
+func (p *Poller) run(ctx context.Context, out chan<- Event) {
+	defer close(out)
+	ticker := time.NewTicker(p.period)
+	defer ticker.Stop()
+
+	current := map[Name]struct{}{}
+	for {
+		select {
+		case <-ctx.Done():
+			plog.Debug("context canceled. Exiting poller")
+			return
+		case <-ticker.C:
+		}
+		names, err := p.interfaces()
+		if err != nil {
+			plog.WithError(err).Warn("can't fetch interfaces. Ignoring this poll")
+			continue
+		}
+		fresh := make(map[Name]struct{}, len(names))
+		for _, name := range names {
+			fresh[name] = struct{}{}
+			if _, ok := current[name]; !ok {
+				if !p.forward(ctx, out, Event{Type: EventAdded, Interface: name}) {
+					return
+				}
+			}
+		}
+		for name := range current {
+			if _, ok := fresh[name]; !ok {
+				if !p.forward(ctx, out, Event{Type: EventDeleted, Interface: name}) {
+					return
+				}
+			}
+		}
+		current = fresh
+	}
+}
+
+func (p *Poller) forward(ctx context.Context, out chan<- Event, ev Event) bool {
+	select {
+	case out <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
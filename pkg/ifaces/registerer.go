@@ -0,0 +1,109 @@
+package ifaces
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+	"golang.org/x/sys/unix"
+)
+
+var rlog = logrus.WithField("component", "ifaces.Registerer")
+
+// Registerer turns the raw netlink.LinkUpdate stream from a Watcher into the same
+// added/deleted Event stream Poller produces. It keeps its own ifindex->name view of the
+// currently known interfaces, so an interface is only reported as added or deleted when that
+// view actually changes: a repeated UP notification for an interface already tracked under the
+// same name, or a DOWN notification for an index/name it doesn't track, generates no event. A
+// tracked link going DOWN is reported as deleted, and as added again when it comes back UP.
+type Registerer struct {
+	watcher *Watcher
+	bufLen  int
+	// ifaces tracks the name of every interface currently considered up, keyed by ifindex,
+	// since that's the only stable identifier a LinkUpdate carries.
+	ifaces map[int]string
+}
+
+// NewRegisterer creates a Registerer that turns the link updates of the given Watcher into an
+// Event stream.
+func NewRegisterer(watcher *Watcher, bufLen int) *Registerer {
+	return &Registerer{watcher: watcher, bufLen: bufLen, ifaces: map[int]string{}}
+}
+
+func (r *Registerer) Subscribe(ctx context.Context) (<-chan Event, error) {
+	current, err := r.watcher.interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("fetching initial interfaces snapshot: %w", err)
+	}
+
+	updates := make(chan netlink.LinkUpdate, r.bufLen)
+	done := make(chan struct{})
+	if err := r.watcher.linkSubscriber(updates, done); err != nil {
+		close(done)
+		return nil, fmt.Errorf("subscribing to link updates: %w", err)
+	}
+
+	out := make(chan Event, r.bufLen)
+	go func() {
+		defer close(out)
+		defer close(done)
+		for _, iface := range current {
+			r.ifaces[iface.Index] = iface.Name
+			if !r.forward(ctx, out, Event{Type: EventAdded, Interface: Name(iface.Name)}) {
+				return
+			}
+		}
+		for {
+			select {
+			case <-ctx.Done():
+				rlog.Debug("context canceled. Exiting registerer")
+				return
+			case update, ok := <-updates:
+				if !ok {
+					return
+				}
+				if !r.handleLinkUpdate(ctx, out, update) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
+
+// handleLinkUpdate reconciles a single LinkUpdate against the tracked ifaces map, forwarding
+// an Event only when the reconciliation actually changes that map. It returns false if the
+// context was canceled while trying to forward an event.
+func (r *Registerer) handleLinkUpdate(ctx context.Context, out chan<- Event, update netlink.LinkUpdate) bool {
+	attrs := update.Link.Attrs()
+	index, name := attrs.Index, attrs.Name
+	up := update.IfInfomsg.Flags&unix.IFF_UP != 0 && update.IfInfomsg.Flags&unix.IFF_RUNNING != 0
+
+	prevName, tracked := r.ifaces[index]
+	switch {
+	case up && (!tracked || prevName != name):
+		if tracked && !r.forward(ctx, out, Event{Type: EventDeleted, Interface: Name(prevName)}) {
+			return false
+		}
+		r.ifaces[index] = name
+		return r.forward(ctx, out, Event{Type: EventAdded, Interface: Name(name)})
+	case !up && tracked && prevName == name:
+		delete(r.ifaces, index)
+		return r.forward(ctx, out, Event{Type: EventDeleted, Interface: Name(name)})
+	default:
+		// either a no-op UP refresh, or a DOWN for an index/name we're not (or no longer)
+		// tracking: the reported interface set doesn't change, so stay silent
+		return true
+	}
+}
+
+func (r *Registerer) forward(ctx context.Context, out chan<- Event, ev Event) bool {
+	select {
+	case out <- ev:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
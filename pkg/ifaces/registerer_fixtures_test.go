@@ -0,0 +1,25 @@
+package ifaces
+
+import (
+	"github.com/vishvananda/netlink"
+	"github.com/vishvananda/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// upAndRunning builds a fake LinkUpdate for an interface that is up and running, as
+// Registerer expects to see it reported right after it comes up.
+func upAndRunning(name string, index int) netlink.LinkUpdate {
+	return netlink.LinkUpdate{
+		IfInfomsg: nl.IfInfomsg{
+			IfInfomsg: unix.IfInfomsg{Flags: unix.IFF_UP | unix.IFF_RUNNING},
+		},
+		Link: &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: name, Index: index}},
+	}
+}
+
+// down builds a fake LinkUpdate for an interface that has gone down.
+func down(name string, index int) netlink.LinkUpdate {
+	return netlink.LinkUpdate{
+		Link: &netlink.Device{LinkAttrs: netlink.LinkAttrs{Name: name, Index: index}},
+	}
+}
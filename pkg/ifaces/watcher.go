@@ -0,0 +1,81 @@
+package ifaces
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
+)
+
+var wlog = logrus.WithField("component", "ifaces.Watcher")
+
+// Interface is a network interface as enumerated by Watcher: its name together with the
+// ifindex the kernel assigns it, needed to correlate later netlink.LinkUpdate notifications
+// (which identify a link by index, not by name) back to the interface they belong to.
+type Interface struct {
+	Name  string
+	Index int
+}
+
+// Watcher is the netlink-backed counterpart of Poller: instead of re-listing the system
+// interfaces on a timer, it subscribes to RTMGRP_LINK notifications via
+// netlink.LinkSubscribeWithOptions and reports an interface change as soon as the kernel
+// notifies it, removing up to one poll interval of discovery latency on nodes with high
+// interface churn (e.g. CNI-driven veth create/delete).
+//
+// Watcher only wraps the raw netlink link source; turning its LinkUpdate stream into the
+// added/deleted Event stream the other Informers produce is Registerer's job, which every
+// Subscribe call on a Watcher delegates to.
+type Watcher struct {
+	bufLen int
+	// interfaces is overridable in tests. It lists the interfaces (with their ifindex)
+	// present before any link update has been received, to seed a Registerer.
+	interfaces func() ([]Interface, error)
+	// linkSubscriber is overridable in tests. It behaves like netlink.LinkSubscribeWithOptions:
+	// it sends link updates on ch until done is closed, then returns.
+	linkSubscriber func(ch chan<- netlink.LinkUpdate, done <-chan struct{}) error
+}
+
+// NewWatcher creates a Watcher that reports interface changes from a netlink RTMGRP_LINK
+// subscription instead of polling.
+func NewWatcher(bufLen int) *Watcher {
+	return &Watcher{
+		bufLen:         bufLen,
+		interfaces:     netInterfacesWithIndex,
+		linkSubscriber: subscribeLinkUpdates,
+	}
+}
+
+// Subscribe implements the Informer interface by layering a Registerer over this Watcher's
+// netlink source.
+func (w *Watcher) Subscribe(ctx context.Context) (<-chan Event, error) {
+	return NewRegisterer(w, w.bufLen).Subscribe(ctx)
+}
+
+// subscribeLinkUpdates is the production linkSubscriber: it forwards RTMGRP_LINK
+// notifications for the network namespace this process runs in, logging (rather than
+// failing) on transient netlink read errors so a single hiccup doesn't tear down the watch.
+func subscribeLinkUpdates(ch chan<- netlink.LinkUpdate, done <-chan struct{}) error {
+	return netlink.LinkSubscribeWithOptions(ch, done, netlink.LinkSubscribeOptions{
+		ErrorCallback: func(err error) {
+			wlog.WithError(err).Warn("error received from the netlink link subscription")
+		},
+	})
+}
+
+// netInterfacesWithIndex is the production Watcher.interfaces: unlike netInterfaces, it also
+// returns each link's ifindex, which Registerer needs to match later LinkUpdate notifications
+// back to the interface they update.
+func netInterfacesWithIndex() ([]Interface, error) {
+	links, err := netlink.LinkList()
+	if err != nil {
+		return nil, fmt.Errorf("can't list links: %w", err)
+	}
+	ifaces := make([]Interface, 0, len(links))
+	for _, link := range links {
+		attrs := link.Attrs()
+		ifaces = append(ifaces, Interface{Name: attrs.Name, Index: attrs.Index})
+	}
+	return ifaces, nil
+}